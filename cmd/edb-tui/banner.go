@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Hauteur du bandeau d'erreur : message, conseil, puis prochain essai et touches
+const bannerLines = 3
+
+// showBanner indique si le bandeau d'erreur est affiché au-dessus des données
+func (m Model) showBanner() bool {
+	return m.err != nil && !m.errDismissed && len(m.data) > 0
+}
+
+// headerHeight renvoie la hauteur de l'en-tête, bandeau d'erreur compris
+func (m Model) headerHeight(l layout) int {
+	if m.showBanner() {
+		return l.headerHeight() + bannerLines
+	}
+	return l.headerHeight()
+}
+
+// errorKeys rappelle les touches utiles après un échec
+func errorKeys() string {
+	return strings.Join(shortHelp([]shortcut{
+		newShortcut("Réessayer", keys.Retry),
+		newShortcut("Masquer", keys.Dismiss),
+	}), "  ")
+}
+
+// renderBanner rend le bandeau d'erreur affiché sous le titre : les
+// dernières données valides restent visibles en dessous
+func (m Model) renderBanner(l layout) string {
+	// Bordure gauche (1) + padding (1)
+	width := l.contentWidth - 2
+	lines := []string{
+		activeTheme.level(2).Render(truncate(levelSymbols[2]+" "+errorMessage(m.err), width)),
+		truncate(errorHint(m.err), width),
+		activeTheme.fg(activeTheme.Muted).Render(truncate(m.retryStatus()+" · "+errorKeys(), width)),
+	}
+	return lipgloss.NewStyle().Border(lipgloss.ThickBorder(), false, false, false, true).BorderForeground(activeTheme.color(activeTheme.Interdit)).PaddingLeft(1).Width(l.contentWidth).Align(lipgloss.Left).Render(strings.Join(lines, "\n"))
+}
+
+// renderFetchError remplace l'écran de chargement quand la toute première
+// récupération a échoué
+func (m Model) renderFetchError(l layout) string {
+	lines := []string{
+		activeTheme.level(2).Render(levelSymbols[2] + " " + errorMessage(m.err)),
+		"",
+		errorHint(m.err),
+		"",
+		activeTheme.fg(activeTheme.Muted).Render(m.retryStatus()),
+		shortHelp([]shortcut{newShortcut("Réessayer", keys.Retry)})[0] + "  " + shortHelp([]shortcut{newShortcut("Quitter", keys.Quit)})[0],
+	}
+	box := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(activeTheme.color(activeTheme.Interdit)).Padding(1, 3).MaxWidth(l.width).Render(strings.Join(lines, "\n"))
+	return lipgloss.Place(l.width, l.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// fetchInfo décrit la récupération en cours, la date des données affichées,
+// ou depuis quand elles sont périmées quand la dernière récupération a échoué
+func (m Model) fetchInfo() (string, lipgloss.Style) {
+	style := activeTheme.fg(activeTheme.OK).Bold(true).Background(activeTheme.border()).Padding(0, 1)
+	switch {
+	case m.fetching:
+		return m.fetchStatus(), style
+	case m.lastSuccess.IsZero():
+		return tr("Données non encore récupérées."), style
+	case m.err != nil:
+		return tr("Données périmées depuis %s (récupérées le %s)", formatAge(m.now.Sub(m.lastSuccess)), formatLongDate(m.lastSuccess)), style.Foreground(activeTheme.color(activeTheme.Passable))
+	}
+	return tr("Données récupérées le %s (source : %s)", formatLongDate(m.lastSuccess), datasetSource(m.displayedDataset())), style
+}
+
+// displayedDataset renvoie le jeu de données affiché, ou celui sélectionné
+// avant les premières données
+func (m Model) displayedDataset() string {
+	if m.shownDataset == "" {
+		return m.dataset
+	}
+	return m.shownDataset
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Bits des points braille (U+2800) pour chaque position d'une cellule 2×4
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// brailleCanvas est une grille de points dessinée avec les caractères braille :
+// chaque cellule de terminal contient 2×4 points, ce qui quadruple la
+// résolution verticale. Des caractères peuvent être posés par-dessus les
+// points (marqueurs, libellés d'axes).
+type brailleCanvas struct {
+	width  int // largeur en cellules
+	height int // hauteur en cellules
+	dots   [][]rune
+	colors [][]string // couleur de la palette du thème
+	glyphs [][]string // caractère posé sur la cellule, prioritaire sur les points
+	styles [][]lipgloss.Style
+}
+
+func newBrailleCanvas(width, height int) *brailleCanvas {
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	c := &brailleCanvas{width: width, height: height}
+	c.dots = make([][]rune, height)
+	c.colors = make([][]string, height)
+	c.glyphs = make([][]string, height)
+	c.styles = make([][]lipgloss.Style, height)
+	for y := range c.dots {
+		c.dots[y] = make([]rune, width)
+		c.colors[y] = make([]string, width)
+		c.glyphs[y] = make([]string, width)
+		c.styles[y] = make([]lipgloss.Style, width)
+	}
+	return c
+}
+
+// dotWidth et dotHeight renvoient la résolution en points
+func (c *brailleCanvas) dotWidth() int  { return c.width * 2 }
+func (c *brailleCanvas) dotHeight() int { return c.height * 4 }
+
+// set allume le point (x, y), l'origine étant en haut à gauche
+func (c *brailleCanvas) set(x, y int, color string) {
+	if x < 0 || y < 0 || x >= c.dotWidth() || y >= c.dotHeight() {
+		return
+	}
+	c.dots[y/4][x/2] |= brailleDots[y%4][x%2]
+	if color != "" {
+		c.colors[y/4][x/2] = color
+	}
+}
+
+// line trace un segment entre deux points (algorithme de Bresenham)
+func (c *brailleCanvas) line(x0, y0, x1, y1 int, color string) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.set(x0, y0, color)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// glyph pose un caractère stylé sur la cellule (cx, cy)
+func (c *brailleCanvas) glyph(cx, cy int, s string, style lipgloss.Style) {
+	if cx < 0 || cy < 0 || cx >= c.width || cy >= c.height {
+		return
+	}
+	c.glyphs[cy][cx] = s
+	c.styles[cy][cx] = style
+}
+
+// text pose une chaîne à partir de la cellule (cx, cy), un caractère par cellule
+func (c *brailleCanvas) text(cx, cy int, s string, style lipgloss.Style) {
+	for i, r := range []rune(s) {
+		c.glyph(cx+i, cy, string(r), style)
+	}
+}
+
+// String rend le canvas ligne par ligne
+func (c *brailleCanvas) String() string {
+	lines := make([]string, c.height)
+	for y := range c.dots {
+		var b strings.Builder
+		for x, d := range c.dots[y] {
+			switch {
+			case c.glyphs[y][x] != "":
+				b.WriteString(c.styles[y][x].Render(c.glyphs[y][x]))
+			case d == 0:
+				b.WriteRune(' ')
+			case c.colors[y][x] != "":
+				b.WriteString(activeTheme.fg(c.colors[y][x]).Render(string(0x2800 + d)))
+			default:
+				b.WriteRune(0x2800 + d)
+			}
+		}
+		lines[y] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// Dimensions du graphique hors zone de tracé : graduations de l'axe Y à
+// gauche, légende au-dessus, axe et dates en dessous
+const (
+	chartLabelWidth = 6
+	chartMinWidth   = chartLabelWidth + 1 + 20
+	chartMinHeight  = 8
+)
+
+// chartPoint est une valeur datée d'une série
+type chartPoint struct {
+	when  time.Time
+	value float64
+}
+
+// chartSeries est la courbe d'un indicateur, dans l'ordre chronologique
+type chartSeries struct {
+	colName string // indicateur, qui donne aussi les seuils tracés
+	color   string // couleur de la palette
+	points  []chartPoint
+}
+
+// chartOverlay est une covariable environnementale tracée sous les courbes,
+// sur sa propre échelle linéaire
+type chartOverlay struct {
+	name   string
+	unit   string
+	points []chartPoint
+}
+
+// top renvoie la valeur maximale de la covariable, au moins 1
+func (o chartOverlay) top() float64 {
+	top := 1.0
+	for _, p := range o.points {
+		top = math.Max(top, p.value)
+	}
+	return top
+}
+
+// timeChart trace l'évolution de E. coli et Enté. dans le temps avec des points
+// braille, avec les seuils de chaque indicateur en pointillés. Le graphique
+// occupe la taille reçue par son dernier tea.WindowSizeMsg.
+type timeChart struct {
+	width    int
+	height   int
+	logScale bool
+	series   []chartSeries
+	overlay  *chartOverlay // covariable superposée, nil sans covariable
+
+	thresholds thresholdProfile // seuils tracés
+}
+
+// newTimeChart prépare le graphique des prélèvements datés d'un point, avec
+// les seuils du profil thresholds
+func newTimeChart(p pointHistory, thresholds thresholdProfile) timeChart {
+	c := timeChart{thresholds: thresholds, series: []chartSeries{
+		{colName: "Enté.", color: activeTheme.Point},
+		{colName: "E. coli", color: activeTheme.Accent},
+	}}
+	for i := range c.series {
+		// Les prélèvements sont du plus récent au plus ancien
+		for j := len(p.samples) - 1; j >= 0; j-- {
+			s := p.samples[j]
+			if m := s.measure(c.series[i].colName); m.ok() && !s.when.IsZero() {
+				c.series[i].points = append(c.series[i].points, chartPoint{s.when, m.number()})
+			}
+		}
+	}
+	return c
+}
+
+func (c timeChart) Update(msg tea.Msg) timeChart {
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		c.width, c.height = msg.Width, msg.Height
+	}
+	return c
+}
+
+// bounds renvoie l'intervalle de temps couvert et la valeur maximale, seuils
+// compris pour qu'ils restent visibles
+func (c timeChart) bounds() (time.Time, time.Time, float64) {
+	var from, to time.Time
+	top := 0.0
+	for _, s := range c.series {
+		_, bad := c.thresholds.limits(s.colName)
+		top = math.Max(top, float64(bad))
+		for _, p := range s.points {
+			if from.IsZero() || p.when.Before(from) {
+				from = p.when
+			}
+			if p.when.After(to) {
+				to = p.when
+			}
+			top = math.Max(top, p.value)
+		}
+	}
+	return from, to, top
+}
+
+// scale renvoie la position d'une valeur sur l'axe Y, entre 0 et 1
+func (c timeChart) scale(v, top float64) float64 {
+	if c.logScale {
+		return math.Log10(math.Max(v, 1)) / math.Log10(math.Max(top, 10))
+	}
+	return v / top
+}
+
+// ticks renvoie les graduations de l'axe Y : puissances de dix en échelle
+// logarithmique, quarts de la valeur maximale sinon
+func (c timeChart) ticks(top float64) []float64 {
+	var ticks []float64
+	if c.logScale {
+		for v := 1.0; v <= top; v *= 10 {
+			ticks = append(ticks, v)
+		}
+		return ticks
+	}
+	for i := 0; i <= 4; i++ {
+		ticks = append(ticks, top*float64(i)/4)
+	}
+	return ticks
+}
+
+func (c timeChart) View() string {
+	if c.width < chartMinWidth || c.height < chartMinHeight {
+		return tr("Agrandissez le terminal pour afficher le graphique.")
+	}
+	from, to, top := c.bounds()
+	if from.IsZero() {
+		return tr("Aucun prélèvement daté.")
+	}
+	if !c.logScale {
+		top = niceCeil(top)
+	}
+	plotW := c.width - chartLabelWidth - 1
+	plotH := c.height - 3
+	canvas := newBrailleCanvas(plotW, plotH)
+	dotW, dotH := canvas.dotWidth(), canvas.dotHeight()
+	y := func(v float64) int {
+		return dotH - 1 - int(math.Round(c.scale(v, top)*float64(dotH-1)))
+	}
+	x := func(t time.Time) int {
+		if !to.After(from) {
+			return dotW / 2
+		}
+		return int(math.Round(float64(t.Sub(from)) / float64(to.Sub(from)) * float64(dotW-1)))
+	}
+
+	// Covariable en arrière-plan, de 0 à sa valeur maximale
+	if o := c.overlay; o != nil {
+		otop := o.top()
+		oy := func(v float64) int {
+			return dotH - 1 - int(math.Round(math.Max(v, 0)/otop*float64(dotH-1)))
+		}
+		for i, p := range o.points {
+			if i == 0 {
+				canvas.set(x(p.when), oy(p.value), activeTheme.Muted)
+				continue
+			}
+			prev := o.points[i-1]
+			canvas.line(x(prev.when), oy(prev.value), x(p.when), oy(p.value), activeTheme.Muted)
+		}
+	}
+	// Seuils en pointillés, libellés à droite dans la couleur de la courbe
+	for _, s := range c.series {
+		good, bad := c.thresholds.limits(s.colName)
+		for level, limit := range []int{good, bad} {
+			ly := y(float64(limit))
+			for dx := 0; dx < dotW; dx++ {
+				if dx%4 < 2 {
+					canvas.set(dx, ly, activeTheme.levelColor(level+1))
+				}
+			}
+			label := fmt.Sprint(limit)
+			canvas.text(plotW-runewidth.StringWidth(label), ly/4, label, activeTheme.fg(s.color))
+		}
+	}
+	// Courbes : segments entre prélèvements successifs
+	for _, s := range c.series {
+		for i, p := range s.points {
+			if i == 0 {
+				canvas.set(x(p.when), y(p.value), s.color)
+				continue
+			}
+			prev := s.points[i-1]
+			canvas.line(x(prev.when), y(prev.value), x(p.when), y(p.value), s.color)
+		}
+	}
+
+	// Axe Y : graduations alignées sur la ligne de leur valeur
+	labels := make([]string, plotH)
+	for _, v := range c.ticks(top) {
+		labels[y(v)/4] = formatTick(v)
+	}
+	plot := strings.Split(canvas.String(), "\n")
+	muted := activeTheme.fg(activeTheme.Muted)
+	lines := []string{c.legend()}
+	for row, line := range plot {
+		axis := "│"
+		if labels[row] != "" {
+			axis = "┤"
+		}
+		lines = append(lines, muted.Render(fmt.Sprintf("%*s", chartLabelWidth, labels[row])+axis)+line)
+	}
+	lines = append(lines, muted.Render(strings.Repeat(" ", chartLabelWidth)+"└"+strings.Repeat("─", plotW)))
+	lines = append(lines, muted.Render(strings.Repeat(" ", chartLabelWidth+1)+dateAxis(from, to, plotW)))
+	return strings.Join(lines, "\n")
+}
+
+// legend renvoie la ligne de légende : couleur de chaque courbe, seuils et échelle
+func (c timeChart) legend() string {
+	var parts []string
+	for i := len(c.series) - 1; i >= 0; i-- {
+		s := c.series[i]
+		parts = append(parts, activeTheme.fg(s.color).Render("━ "+tr(s.colName)))
+	}
+	if o := c.overlay; o != nil {
+		label := o.name
+		if o.unit != "" {
+			label += " (" + o.unit + ")"
+		}
+		parts = append(parts, activeTheme.fg(activeTheme.Muted).Render("━ "+label+" "+tr("max %s", formatTick(o.top()))))
+	}
+	parts = append(parts, activeTheme.level(1).Render("┄")+activeTheme.level(2).Render("┄")+" "+tr("seuils"))
+	scale := tr("échelle linéaire")
+	if c.logScale {
+		scale = tr("échelle logarithmique")
+	}
+	parts = append(parts, lipgloss.NewStyle().Faint(true).Render(scale))
+	return truncate(strings.Join(parts, "   "), c.width)
+}
+
+// niceCeil arrondit une valeur maximale au-dessus, à un multiple d'une
+// puissance de dix dont le quart tombe juste (1, 2, 4, 5 ou 8)
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	pow := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, f := range []float64{1, 2, 4, 5, 8, 10} {
+		if f*pow >= v {
+			return f * pow
+		}
+	}
+	return 10 * pow
+}
+
+// formatTick formate une graduation, en milliers au-delà de 1000 (1.5k, 10k)
+func formatTick(v float64) string {
+	switch {
+	case v >= 1000 && v < 10000 && math.Mod(v, 1000) != 0:
+		return fmt.Sprintf("%.1fk", v/1000)
+	case v >= 1000:
+		return fmt.Sprintf("%.0fk", v/1000)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// dateAxis place la première et la dernière date sous l'axe, et celle du
+// milieu si la place le permet
+func dateAxis(from, to time.Time, width int) string {
+	first, last := formatDate(from), formatDate(to)
+	if !to.After(from) {
+		return padRight("", (width-runewidth.StringWidth(first))/2) + first
+	}
+	axis := []rune(strings.Repeat(" ", width))
+	put := func(pos int, s string) {
+		for i, r := range []rune(s) {
+			if pos+i >= 0 && pos+i < len(axis) {
+				axis[pos+i] = r
+			}
+		}
+	}
+	put(0, first)
+	put(width-runewidth.StringWidth(last), last)
+	if mid := formatDate(from.Add(to.Sub(from) / 2)); width >= 3*runewidth.StringWidth(mid)+4 {
+		put((width-runewidth.StringWidth(mid))/2, mid)
+	}
+	return string(axis)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/term"
+)
+
+// Taille par défaut du graphique de la commande chart hors d'un terminal
+const (
+	chartDefaultWidth  = 80
+	chartDefaultHeight = 20
+)
+
+// runChart exécute la commande "edb chart" : graphique des prélèvements des
+// points d'une plage, écrit sur la sortie standard sans interface interactive.
+// Renvoie le code de sortie du programme.
+func runChart(args []string) int {
+	fs := flag.NewFlagSet("chart", flag.ContinueOnError)
+	beach := fs.String("beach", "", "plage à tracer (nom ou partie du nom, sans tenir compte de la casse)")
+	width := fs.Int("width", 0, "largeur du graphique ; par défaut celle du terminal")
+	height := fs.Int("height", chartDefaultHeight, "hauteur du graphique de chaque point")
+	logScale := fs.Bool("log", false, "axe des valeurs en échelle logarithmique")
+	lang := fs.String("lang", "", "langue de l'interface (fr, en) ; par défaut selon LANG")
+	from := fs.String("from", "", "ne trace que les prélèvements à partir de cette date (AAAA-MM-JJ)")
+	to := fs.String("to", "", "ne trace que les prélèvements jusqu'à cette date incluse (AAAA-MM-JJ)")
+	dataset := fs.String("dataset", "", "jeu de données (nom du registre, ou all) ; par défaut celui de la configuration")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *lang == "" {
+		*lang = envLang()
+	}
+	if err := setLocale(*lang); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 2
+	}
+	if *beach == "" {
+		fmt.Fprintln(os.Stderr, tr("Précisez la plage avec --beach"))
+		return 2
+	}
+	period := datePeriod{kind: periodAll}
+	if *from != "" || *to != "" {
+		var err error
+		if period, err = customPeriod(*from, *to); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+			return 2
+		}
+	}
+	var selection string
+	var thresholds thresholdProfile
+	cfg, err := loadConfig()
+	if err == nil {
+		activeTheme, err = loadTheme(cfg.Theme)
+	}
+	if err == nil {
+		thresholds, err = cfg.thresholds()
+	}
+	if err == nil {
+		selection, err = cfg.datasets(*dataset)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Erreur de configuration : %v", err))
+		return 1
+	}
+	if *width <= 0 {
+		*width = chartDefaultWidth
+		if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+			*width = w
+		}
+	}
+
+	details, err := fetchDetails(selection)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Erreur: %v", err))
+		return 1
+	}
+	// La période s'applique comme dans l'interface : aux prélèvements tracés
+	details = period.filter(time.Now()).rows(details)
+	points := beachPoints(groupByPoint(parseSamples(details)), *beach)
+	if len(points) == 0 && period.kind != periodAll {
+		fmt.Fprintln(os.Stderr, tr("Aucun prélèvement de %q sur la période (%s)", *beach, period.label(time.Now())))
+		return 1
+	}
+	if len(points) == 0 {
+		fmt.Fprintln(os.Stderr, tr("Aucune plage ne correspond à %q", *beach))
+		return 1
+	}
+	for i, p := range points {
+		if i > 0 {
+			lipgloss.Println()
+		}
+		c := newTimeChart(p, thresholds)
+		c.logScale = *logScale
+		c = c.Update(tea.WindowSizeMsg{Width: *width, Height: *height})
+		title := p.site + " – " + p.point
+		if period.kind != periodAll {
+			title += " (" + period.label(time.Now()) + ")"
+		}
+		lipgloss.Println(activeTheme.header().Render(truncate(title, *width)))
+		lipgloss.Println(c.View())
+	}
+	return 0
+}
+
+// beachPoints renvoie les points des plages dont le nom contient name, sans
+// tenir compte de la casse
+func beachPoints(points []pointHistory, name string) []pointHistory {
+	var found []pointHistory
+	for _, p := range points {
+		if strings.Contains(strings.ToLower(p.site), strings.ToLower(name)) {
+			found = append(found, p)
+		}
+	}
+	return found
+}
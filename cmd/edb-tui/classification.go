@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Classement d'un point de prélèvement selon la directive 2006/7/CE (annexe II,
+// eaux côtières et de transition), du meilleur au moins bon
+const (
+	classExcellent = iota
+	classGood
+	classSufficient
+	classPoor
+	classNone // trop peu de prélèvements pour calculer les percentiles
+)
+
+// Noms des classes de qualité de la directive
+var classNames = []string{"excellente", "bonne", "suffisante", "insuffisante", "non classé"}
+
+// Niveau de couleur de chaque classe (le niveau de classNone n'est pas utilisé)
+var classLevels = []int{0, 0, 1, 2, 0}
+
+const (
+	classPeriodYears = 4  // période d'évaluation : les quatre dernières années
+	classMinSamples  = 16 // nombre de prélèvements exigé par la directive
+	classMaxDrivers  = 3  // prélèvements déterminants affichés
+)
+
+// classCriterion est le critère d'une classe : percentile évalué et seuils
+// E. coli et Enté. à ne pas dépasser
+type classCriterion struct {
+	class      int
+	percentile int // 95 ou 90
+	ecoli      float64
+	ente       float64
+}
+
+// Critères de la directive pour les eaux côtières, de la meilleure classe à la
+// moins bonne ; une eau qui n'en respecte aucun est de qualité insuffisante
+var coastalCriteria = []classCriterion{
+	{class: classExcellent, percentile: 95, ecoli: 250, ente: 100},
+	{class: classGood, percentile: 95, ecoli: 500, ente: 200},
+	{class: classSufficient, percentile: 90, ecoli: 500, ente: 185},
+}
+
+// Quantiles de la loi normale utilisés par la directive pour les percentiles
+var normalQuantiles = map[int]float64{95: 1.65, 90: 1.282}
+
+// logNormalFit décrit la distribution log-normale d'un indicateur : moyenne
+// et écart type des log10 des valeurs
+type logNormalFit struct {
+	count int
+	mu    float64
+	sigma float64
+}
+
+// fitLogNormal ajuste une loi log-normale aux valeurs ; les valeurs nulles sont
+// comptées comme 1 pour que leur logarithme soit défini
+func fitLogNormal(values []float64) logNormalFit {
+	f := logNormalFit{count: len(values)}
+	if f.count == 0 {
+		return f
+	}
+	for _, v := range values {
+		f.mu += math.Log10(math.Max(v, 1))
+	}
+	f.mu /= float64(f.count)
+	if f.count < 2 {
+		return f
+	}
+	for _, v := range values {
+		d := math.Log10(math.Max(v, 1)) - f.mu
+		f.sigma += d * d
+	}
+	f.sigma = math.Sqrt(f.sigma / float64(f.count-1))
+	return f
+}
+
+// percentile renvoie le percentile (95 ou 90) : antilog(μ + k·σ)
+func (f logNormalFit) percentile(p int) float64 {
+	return math.Pow(10, f.mu+normalQuantiles[p]*f.sigma)
+}
+
+// classFailure est un critère non respecté : il explique pourquoi la classe
+// supérieure n'est pas atteinte
+type classFailure struct {
+	colName   string
+	criterion classCriterion
+	value     float64 // percentile calculé
+	limit     float64 // seuil dépassé
+}
+
+// classification est le classement d'un point et les éléments qui l'expliquent
+type classification struct {
+	class    int
+	count    int                     // prélèvements de la période d'évaluation
+	fits     map[string]logNormalFit // par indicateur ("E. coli", "Enté.")
+	failures []classFailure          // critères manqués pour la classe supérieure
+	drivers  []sample                // prélèvements au-delà du seuil manqué, du pire au moins mauvais
+}
+
+// classify calcule le classement d'un point à partir de ses prélèvements des
+// quatre années précédant le plus récent
+func classify(p pointHistory) classification {
+	samples := assessmentSamples(p.samples)
+	c := classification{class: classNone, count: len(samples), fits: map[string]logNormalFit{}}
+	for _, colName := range []string{"E. coli", "Enté."} {
+		c.fits[colName] = fitLogNormal(indicatorValues(samples, colName))
+	}
+	if c.fits["E. coli"].count < 2 || c.fits["Enté."].count < 2 {
+		return c
+	}
+	c.class = classPoor
+	for _, crit := range coastalCriteria {
+		failures := c.check(crit)
+		if len(failures) == 0 {
+			c.class = crit.class
+			break
+		}
+		// Seuls les critères de la classe immédiatement supérieure expliquent le classement
+		c.failures = failures
+	}
+	if len(c.failures) > 0 {
+		c.drivers = classDrivers(samples, c.failures)
+	}
+	return c
+}
+
+// check renvoie les indicateurs dont le percentile dépasse le seuil du critère
+func (c classification) check(crit classCriterion) []classFailure {
+	var failures []classFailure
+	for _, f := range []classFailure{
+		{colName: "E. coli", criterion: crit, limit: crit.ecoli},
+		{colName: "Enté.", criterion: crit, limit: crit.ente},
+	} {
+		f.value = c.fits[f.colName].percentile(crit.percentile)
+		if f.value > f.limit {
+			failures = append(failures, f)
+		}
+	}
+	return failures
+}
+
+// assessmentSamples renvoie les prélèvements de la période d'évaluation ; les
+// prélèvements sans date lisible sont conservés
+func assessmentSamples(samples []sample) []sample {
+	var latest time.Time
+	for _, s := range samples {
+		if s.when.After(latest) {
+			latest = s.when
+		}
+	}
+	if latest.IsZero() {
+		return samples
+	}
+	start := latest.AddDate(-classPeriodYears, 0, 0)
+	var kept []sample
+	for _, s := range samples {
+		if s.when.IsZero() || !s.when.Before(start) {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// classDrivers renvoie les prélèvements qui dépassent le seuil d'un critère
+// manqué, du pire au moins mauvais
+func classDrivers(samples []sample, failures []classFailure) []sample {
+	type driver struct {
+		s     sample
+		ratio float64 // valeur rapportée au seuil dépassé
+	}
+	var drivers []driver
+	for _, s := range samples {
+		worst := 0.0
+		for _, f := range failures {
+			if m := s.measure(f.colName); m.ok() && m.number() > f.limit {
+				worst = math.Max(worst, m.number()/f.limit)
+			}
+		}
+		if worst > 0 {
+			drivers = append(drivers, driver{s, worst})
+		}
+	}
+	sort.SliceStable(drivers, func(i, j int) bool { return drivers[i].ratio > drivers[j].ratio })
+	var out []sample
+	for i := 0; i < len(drivers) && i < classMaxDrivers; i++ {
+		out = append(out, drivers[i].s)
+	}
+	return out
+}
+
+// sampleValue renvoie la valeur brute d'un indicateur d'un prélèvement
+func sampleValue(s sample, colName string) string {
+	if colName == "Enté." {
+		return s.ente
+	}
+	return s.ecoli
+}
+
+// label renvoie le nom de la classe, précédé du symbole de sa couleur
+func (c classification) label() string {
+	if c.class == classNone {
+		return tr(classNames[classNone])
+	}
+	return withSymbol(tr(classNames[c.class]), classLevels[c.class])
+}
+
+// headline résume le classement sur une ligne
+func (c classification) headline() string {
+	return tr("Classement 2006/7/CE : %s", c.label())
+}
+
+// explain détaille le calcul : percentiles de chaque indicateur, critères
+// manqués pour la classe supérieure et prélèvements qui en sont la cause
+func (c classification) explain() []string {
+	if c.class == classNone {
+		return []string{tr("Au moins deux prélèvements de chaque indicateur sont nécessaires.")}
+	}
+	lines := []string{tr("%d prélèvements sur %d ans, percentiles log-normaux :", c.count, classPeriodYears)}
+	for _, colName := range []string{"E. coli", "Enté."} {
+		f := c.fits[colName]
+		lines = append(lines, tr("%s : P95 %.0f, P90 %.0f", tr(colName), f.percentile(95), f.percentile(90)))
+	}
+	if c.class == classExcellent {
+		lines = append(lines, tr("Tous les critères de qualité excellente sont respectés."))
+	}
+	for _, f := range c.failures {
+		lines = append(lines, tr("Qualité %s manquée : P%d %s %.0f > %.0f", tr(classNames[f.criterion.class]), f.criterion.percentile, tr(f.colName), f.value, f.limit))
+	}
+	if len(c.drivers) > 0 {
+		lines = append(lines, tr("Prélèvements déterminants :"))
+		for _, s := range c.drivers {
+			lines = append(lines, fmt.Sprintf("  %s  %s %s, %s %s", s.date, tr("E. coli"), s.measure("E. coli").display(), tr("Enté."), s.measure("Enté.").display()))
+		}
+	}
+	if c.count < classMinSamples {
+		lines = append(lines, tr("Indicatif : la directive exige %d prélèvements.", classMinSamples))
+	}
+	return lines
+}
+
+// classCell renvoie le texte affiché d'une cellule de la colonne Classement
+func classCell(cell string) string {
+	class := slices.Index(classNames, cell)
+	if class < 0 || class == classNone {
+		return tr(cell)
+	}
+	return withSymbol(tr(cell), classLevels[class])
+}
+
+// classStyle renvoie le style d'une cellule de la colonne Classement
+func classStyle(cell string) lipgloss.Style {
+	class := slices.Index(classNames, cell)
+	if class < 0 || class == classNone {
+		return activeTheme.fg(activeTheme.Muted)
+	}
+	return activeTheme.level(classLevels[class])
+}
+
+// classifyPoints calcule le classement de chaque point, indexé par sa clé
+// (voir rowPointKey)
+func classifyPoints(details [][]string) map[string]classification {
+	classes := map[string]classification{}
+	for _, p := range groupByPoint(parseSamples(details)) {
+		classes[p.key] = classify(p)
+	}
+	return classes
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Percentiles log-normaux de la directive : antilog(μ + k·σ) sur les log10
+// des valeurs, une valeur nulle comptant pour 1
+func TestLogNormalPercentile(t *testing.T) {
+	tests := []struct {
+		values []float64
+		p      int
+		want   float64
+	}{
+		// μ = 2, σ = 1
+		{[]float64{10, 100, 1000}, 95, math.Pow(10, 3.65)},
+		{[]float64{10, 100, 1000}, 90, math.Pow(10, 3.282)},
+		// Série constante : σ = 0, le percentile est la valeur
+		{[]float64{100, 100, 100}, 95, 100},
+		// μ = 1, σ = √2
+		{[]float64{0, 100}, 95, math.Pow(10, 1+1.65*math.Sqrt2)},
+	}
+	for _, tt := range tests {
+		got := fitLogNormal(tt.values).percentile(tt.p)
+		if math.Abs(got-tt.want) > 1e-6*tt.want {
+			t.Errorf("P%d de %v = %v, attendu %v", tt.p, tt.values, got, tt.want)
+		}
+	}
+}
+
+// Le classement retient la meilleure classe dont les deux critères sont
+// respectés, et rien sous deux prélèvements
+func TestClassify(t *testing.T) {
+	point := func(ecoli, ente []string) pointHistory {
+		var p pointHistory
+		for i := range ecoli {
+			p.samples = append(p.samples, sample{ecoli: ecoli[i], ente: ente[i]})
+		}
+		return p
+	}
+	tests := []struct {
+		name  string
+		ecoli []string
+		ente  []string
+		want  int
+	}{
+		{"un prélèvement", []string{"10"}, []string{"10"}, classNone},
+		{"excellente", []string{"10", "<10", "20"}, []string{"10", "10", "15"}, classExcellent},
+		// P95 E. coli = 10^3.65 ≈ 4467, P90 ≈ 1914 : au-delà de tous les critères
+		{"insuffisante", []string{"10", "100", "1000"}, []string{"10", "10", "10"}, classPoor},
+	}
+	for _, tt := range tests {
+		if got := classify(point(tt.ecoli, tt.ente)).class; got != tt.want {
+			t.Errorf("%s : classe %d, attendu %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// Formats de copie des détails, dans l'ordre de la touche de changement
+const (
+	copyText = iota
+	copyMarkdown
+	copyCSV
+)
+
+// Noms des formats de copie dans la configuration et dans le journal
+var copyFormatNames = []string{"text", "markdown", "csv"}
+
+// Libellés des formats de copie
+var copyFormatLabels = []string{"texte", "Markdown", "CSV"}
+
+// defaultCopyFormat est le format de copie au démarrage (configuration)
+var defaultCopyFormat = copyText
+
+// parseCopyFormat renvoie le format de copie du nom donné
+func parseCopyFormat(name string) (int, error) {
+	if name == "" {
+		return copyText, nil
+	}
+	for i, n := range copyFormatNames {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("format de copie %q inconnu (%s)", name, strings.Join(copyFormatNames, ", "))
+}
+
+// Colonnes copiées, dans l'ordre ; l'état est calculé depuis les indicateurs
+var copyColumns = []string{"Site", "Point de prélèvement", "Date", "E. coli", "Enté."}
+
+// copyMsg demande au modèle racine de copier un texte dans le presse-papiers
+type copyMsg struct {
+	text   string
+	rows   int // nombre de prélèvements copiés
+	format int
+}
+
+// copyCmd envoie au modèle racine les lignes à copier
+func copyCmd(p thresholdProfile, header []string, rows [][]string, format int) tea.Cmd {
+	return func() tea.Msg { return copyRows(p, header, rows, format) }
+}
+
+// copyRows met en forme des lignes du tableau des détails (header en tête)
+// pour le presse-papiers
+func copyRows(p thresholdProfile, header []string, rows [][]string, format int) copyMsg {
+	table := [][]string{append(translated(copyColumns), tr("État"))}
+	for _, row := range rows {
+		table = append(table, copyRecord(p, header, row))
+	}
+	var text string
+	switch format {
+	case copyMarkdown:
+		text = markdownTable(table)
+	case copyCSV:
+		text = csvTable(table)
+	default:
+		if len(rows) == 1 {
+			r := table[1]
+			place := r[0]
+			if r[1] != "" {
+				place += ", " + r[1]
+			}
+			text = tr("%s — %s : E. coli %s, Enté. %s (%s)", place, r[2], r[3], r[4], r[5]) + "\n"
+		} else {
+			text = textTable(table)
+		}
+	}
+	return copyMsg{text: text, rows: len(rows), format: format}
+}
+
+// copyRecord extrait les colonnes copiées d'une ligne et y ajoute l'état
+func copyRecord(p thresholdProfile, header, row []string) []string {
+	var record []string
+	level := 0
+	for _, name := range copyColumns {
+		value := ""
+		if j := columnIndex(header, name); j >= 0 && j < len(row) {
+			value = row[j]
+		}
+		if name == "E. coli" || name == "Enté." {
+			if m := parseMeasurement(value); m.ok() {
+				level = max(level, m.level(p, name))
+			}
+		}
+		record = append(record, value)
+	}
+	return append(record, tr(levelNames[level]))
+}
+
+// translated traduit une liste de libellés
+func translated(names []string) []string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = tr(n)
+	}
+	return out
+}
+
+// textTable aligne les colonnes avec des espaces (largeur d'affichage)
+func textTable(table [][]string) string {
+	widths := make([]int, len(table[0]))
+	for _, row := range table {
+		for j, cell := range row {
+			widths[j] = max(widths[j], runewidth.StringWidth(cell))
+		}
+	}
+	var lines []string
+	for _, row := range table {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = padRight(cell, widths[j])
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// markdownTable met en forme un tableau Markdown
+func markdownTable(table [][]string) string {
+	var b strings.Builder
+	for i, row := range table {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", len(row)) + "|\n")
+		}
+	}
+	return b.String()
+}
+
+// csvTable met en forme un CSV
+func csvTable(table [][]string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.WriteAll(table)
+	return b.String()
+}
+
+// clipboardSequence encadre le texte dans une séquence OSC 52, elle-même
+// transmise telle quelle par tmux ou screen au terminal qui les héberge
+func clipboardSequence(text string, env func(string) string) osc52.Sequence {
+	seq := osc52.New(text)
+	switch {
+	case env("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(env("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	return seq
+}
+
+// syncWriter sérialise les écritures sur un terminal : les séquences OSC 52,
+// écrites depuis une commande, passent par la sortie du programme et ne
+// s'intercalent jamais au milieu d'une image du rendu
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// syncFile est la sortie sérialisée du terminal local ; le fichier reste
+// visible de Bubbletea, qui y lit la taille du terminal et y règle le mode brut
+type syncFile struct {
+	*os.File
+	out *syncWriter
+}
+
+func newSyncFile(f *os.File) syncFile {
+	return syncFile{File: f, out: &syncWriter{w: f}}
+}
+
+func (f syncFile) Write(p []byte) (int, error) { return f.out.Write(p) }
+
+func (f syncFile) WriteString(s string) (int, error) { return f.out.Write([]byte(s)) }
+
+// copyToClipboard écrit la séquence OSC 52 sur le terminal, par la même
+// sortie sérialisée que le rendu : le terminal copie le texte dans le
+// presse-papiers du poste, y compris via SSH
+func copyToClipboard(w io.Writer, env func(string) string, text string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := clipboardSequence(text, env).WriteTo(w); err != nil {
+			return clipboardErrMsg{err}
+		}
+		return nil
+	}
+}
+
+// copyFormatMsg signale le nouveau format de copie, noté dans le journal
+type copyFormatMsg int
+
+// clipboardErrMsg signale l'échec de l'écriture de la séquence OSC 52
+type clipboardErrMsg struct{ err error }
+
+// copied copie le texte demandé et le note dans le journal
+func (m Model) copied(msg copyMsg) (Model, tea.Cmd) {
+	m = m.addLog(slog.LevelInfo, tr("%d prélèvement(s) copié(s) dans le presse-papiers (%s)", msg.rows, tr(copyFormatLabels[msg.format])), "format", copyFormatNames[msg.format])
+	return m, copyToClipboard(m.clipboard, m.getenv, msg.text)
+}
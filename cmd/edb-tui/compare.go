@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// compareWindow est le nombre de derniers prélèvements comparés (statistiques,
+// taux de dépassement et sparklines)
+const compareWindow = 12
+
+// markSymbol repère dans le tableau des détails les points marqués
+const markSymbol = "◆"
+
+// Blocs des sparklines, du plus bas au plus haut
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// compareMsg demande au modèle racine d'ouvrir la comparaison des points marqués
+type compareMsg struct{ points []pointHistory }
+
+// pointComparison résume un point de prélèvement sur ses derniers prélèvements
+type pointComparison struct {
+	point    pointHistory
+	recent   []sample // compareWindow derniers prélèvements, du plus récent au plus ancien
+	class    classification
+	measured int // prélèvements avec au moins une valeur utilisable
+	exceeded int // prélèvements dont un indicateur dépasse son premier seuil
+
+	thresholds thresholdProfile // seuils des niveaux affichés
+}
+
+// comparePoint calcule le résumé d'un point selon les seuils du profil
+// thresholds
+func comparePoint(p pointHistory, thresholds thresholdProfile) pointComparison {
+	c := pointComparison{point: p, recent: p.samples[:min(len(p.samples), compareWindow)], class: classify(p), thresholds: thresholds}
+	for _, s := range c.recent {
+		measured, exceeded := false, false
+		for _, colName := range []string{"E. coli", "Enté."} {
+			if m := s.measure(colName); m.ok() {
+				measured = true
+				exceeded = exceeded || m.level(thresholds, colName) > 0
+			}
+		}
+		if measured {
+			c.measured++
+		}
+		if exceeded {
+			c.exceeded++
+		}
+	}
+	return c
+}
+
+// compareCell est une cellule du tableau de comparaison : un style pour tout
+// le texte, ou un style par caractère pour les sparklines
+type compareCell struct {
+	text   string
+	style  lipgloss.Style
+	blocks []lipgloss.Style // style de chaque caractère, nil hors sparkline
+}
+
+func plainCell(text string, style lipgloss.Style) compareCell {
+	return compareCell{text: text, style: style}
+}
+
+// render tronque et complète la cellule à la largeur de la colonne
+func (c compareCell) render(width int) string {
+	text := padRight(truncate(c.text, width), width)
+	if c.blocks == nil {
+		return c.style.Render(text)
+	}
+	var b strings.Builder
+	for i, r := range []rune(text) {
+		if i < len(c.blocks) {
+			b.WriteString(c.blocks[i].Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// latestCell renvoie la valeur d'un indicateur au dernier prélèvement
+func (c pointComparison) latestCell(colName string) compareCell {
+	if len(c.recent) == 0 {
+		return plainCell("–", activeTheme.fg(activeTheme.Muted))
+	}
+	cell := sampleValue(c.recent[0], colName)
+	if strings.TrimSpace(cell) == "" {
+		return plainCell("–", activeTheme.fg(activeTheme.Muted))
+	}
+	return plainCell(indicatorCell(c.thresholds, colName, cell), measureStyle(c.thresholds, colName, cell))
+}
+
+// statCell renvoie une statistique d'un indicateur sur les derniers
+// prélèvements, colorée selon les seuils
+func (c pointComparison) statCell(colName string, stat func(summary) float64) compareCell {
+	s := summarize(indicatorValues(c.recent, colName))
+	if s.count == 0 {
+		return plainCell("–", activeTheme.fg(activeTheme.Muted))
+	}
+	v := stat(s)
+	level := c.thresholds.level(colName, int(math.Ceil(v)))
+	return plainCell(withSymbol(fmt.Sprintf("%.0f", v), level), activeTheme.level(level))
+}
+
+// exceedCell renvoie le taux de prélèvements au-dessus d'un seuil
+func (c pointComparison) exceedCell() compareCell {
+	if c.measured == 0 {
+		return plainCell("–", activeTheme.fg(activeTheme.Muted))
+	}
+	level := 0
+	if c.exceeded > 0 {
+		level = 1
+	}
+	if 2*c.exceeded > c.measured {
+		level = 2
+	}
+	return plainCell(tr("%d %% (%d/%d)", 100*c.exceeded/c.measured, c.exceeded, c.measured), activeTheme.level(level))
+}
+
+// sparkCell trace les derniers prélèvements d'un indicateur dans l'ordre
+// chronologique, en échelle logarithmique jusqu'à top ; chaque bloc prend la
+// couleur du niveau de la valeur
+func (c pointComparison) sparkCell(colName string, top float64) compareCell {
+	cell := compareCell{blocks: []lipgloss.Style{}}
+	for i := len(c.recent) - 1; i >= 0; i-- {
+		m := c.recent[i].measure(colName)
+		if !m.ok() {
+			cell.text += " "
+			cell.blocks = append(cell.blocks, lipgloss.NewStyle())
+			continue
+		}
+		pos := math.Log10(math.Max(m.number(), 1)) / math.Log10(top)
+		block := int(math.Round(pos * float64(len(sparkBlocks)-1)))
+		cell.text += string(sparkBlocks[min(max(block, 0), len(sparkBlocks)-1)])
+		cell.blocks = append(cell.blocks, activeTheme.fg(activeTheme.levelColor(m.level(c.thresholds, colName))))
+	}
+	return cell
+}
+
+// sparkTop renvoie la valeur la plus haute des sparklines, commune à tous les
+// points comparés pour que les courbes se superposent à la même échelle
+func sparkTop(points []pointComparison) float64 {
+	top := 10.0
+	for _, c := range points {
+		for _, colName := range []string{"E. coli", "Enté."} {
+			for _, v := range indicatorValues(c.recent, colName) {
+				top = math.Max(top, v)
+			}
+		}
+	}
+	return top
+}
+
+// openCompare ouvre la popup de comparaison, qui demande au moins deux points
+func (m Model) openCompare(msg compareMsg) Model {
+	if len(msg.points) < 2 {
+		return m.addLog(slog.LevelWarn, tr("Marquez au moins deux plages avec %s pour les comparer", keys.Mark.Help().Key))
+	}
+	m.compare = msg.points
+	m.showCompare = true
+	return m
+}
+
+// renderComparePopup affiche les points marqués côte à côte, un point par
+// colonne et un indicateur par ligne
+func (m Model) renderComparePopup(l layout) string {
+	var points []pointComparison
+	for _, p := range m.compare {
+		points = append(points, comparePoint(p, m.thresholds))
+	}
+	top := sparkTop(points)
+	muted := activeTheme.fg(activeTheme.Muted)
+	rows := []struct {
+		label string
+		cell  func(c pointComparison) compareCell
+	}{
+		{"Plage", func(c pointComparison) compareCell { return plainCell(c.point.site, activeTheme.header()) }},
+		{"Point de prélèvement", func(c pointComparison) compareCell {
+			return plainCell(c.point.point, activeTheme.fg(activeTheme.Point).Bold(true))
+		}},
+		{"Dernier prélèvement", func(c pointComparison) compareCell {
+			if len(c.recent) == 0 {
+				return plainCell("–", muted)
+			}
+			if s := c.recent[0]; !s.when.IsZero() {
+				return plainCell(formatDate(s.when), activeTheme.fg(activeTheme.Text))
+			}
+			return plainCell(c.recent[0].date, activeTheme.fg(activeTheme.Text))
+		}},
+		{"E. coli", func(c pointComparison) compareCell { return c.latestCell("E. coli") }},
+		{"Enté.", func(c pointComparison) compareCell { return c.latestCell("Enté.") }},
+		{"Moy. géo. E. coli", func(c pointComparison) compareCell {
+			return c.statCell("E. coli", func(s summary) float64 { return s.geomean })
+		}},
+		{"Moy. géo. Enté.", func(c pointComparison) compareCell {
+			return c.statCell("Enté.", func(s summary) float64 { return s.geomean })
+		}},
+		{"Max E. coli", func(c pointComparison) compareCell {
+			return c.statCell("E. coli", func(s summary) float64 { return s.max })
+		}},
+		{"Max Enté.", func(c pointComparison) compareCell {
+			return c.statCell("Enté.", func(s summary) float64 { return s.max })
+		}},
+		{"Dépassements", func(c pointComparison) compareCell { return c.exceedCell() }},
+		{"Classement", func(c pointComparison) compareCell {
+			cell := classNames[c.class.class]
+			return plainCell(classCell(cell), classStyle(cell))
+		}},
+		{"Évolution E. coli", func(c pointComparison) compareCell { return c.sparkCell("E. coli", top) }},
+		{"Évolution Enté.", func(c pointComparison) compareCell { return c.sparkCell("Enté.", top) }},
+	}
+
+	// Largeurs calculées sur le texte brut : libellés, puis une colonne par
+	// point, réduite pour tenir dans la popup (bordure 2, padding 2*4)
+	labelWidth := 0
+	cells := make([][]compareCell, len(rows))
+	colWidth := compareWindow
+	for i, row := range rows {
+		labelWidth = max(labelWidth, runewidth.StringWidth(tr(row.label)))
+		for _, c := range points {
+			cell := row.cell(c)
+			cells[i] = append(cells[i], cell)
+			colWidth = max(colWidth, runewidth.StringWidth(cell.text))
+		}
+	}
+	if avail := (l.width - 10 - labelWidth) / len(points); colWidth > avail-2 {
+		colWidth = max(avail-2, 6)
+	}
+
+	lines := []string{
+		activeTheme.header().Render(tr("Comparaison des plages marquées")),
+		lipgloss.NewStyle().Faint(true).Render(tr("%d derniers prélèvements, sparklines en échelle logarithmique commune", compareWindow)),
+		"",
+	}
+	for i, row := range rows {
+		line := lipgloss.NewStyle().Bold(true).Render(padRight(tr(row.label), labelWidth))
+		for _, cell := range cells[i] {
+			line += "  " + cell.render(colWidth)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", tr("Appuyez sur une touche pour fermer."))
+	return lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).BorderForeground(activeTheme.color(activeTheme.OK)).Padding(1, 4).MaxWidth(l.width).Render(strings.Join(lines, "\n"))
+}
+
+// markedPoints renvoie l'historique des points marqués, triés par site puis
+// par point
+func markedPoints(details [][]string, marked map[string]bool) []pointHistory {
+	var points []pointHistory
+	for _, p := range groupByPoint(parseSamples(details)) {
+		if marked[p.key] {
+			points = append(points, p)
+		}
+	}
+	return points
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// config est le contenu du fichier de configuration JSON, facultatif :
+// les valeurs absentes gardent leur valeur par défaut
+type config struct {
+	// Touches par action, par exemple {"up": ["k", "up"]} ; voir keyMap.named
+	Keys map[string][]string `json:"keys"`
+	// Thème intégré (dark, light, high-contrast, okabe-ito) ou fichier
+	// themes/<nom>.json du dossier de configuration
+	Theme string `json:"theme"`
+	// Intervalle du rafraîchissement automatique, par exemple "30m" ou "2h"
+	RefreshInterval string `json:"refresh_interval"`
+	// Format de copie dans le presse-papiers : "text", "markdown" ou "csv"
+	CopyFormat string `json:"copy_format"`
+	// Saison balnéaire, jours de début et de fin au format MM-JJ, par
+	// exemple {"start": "11-01", "end": "03-31"}
+	BathingSeason struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	} `json:"bathing_season"`
+	// Profil de seuils au démarrage : eu-inland (par défaut), eu-coastal,
+	// who ou local
+	Thresholds string `json:"thresholds"`
+	// Profil de seuils local, par exemple
+	// {"label": "Arrêté municipal", "ecoli": [400, 800], "ente": [150, 300]}
+	LocalThresholds *thresholdProfile `json:"local_thresholds"`
+	// Covariables environnementales (pluie, marée, vent) lues dans des
+	// fichiers CSV locaux, superposées au graphique de l'onglet Historique
+	Covariates []covariateConfig `json:"covariates"`
+	// Jeux de données ajoutés au jeu intégré de Nouméa, et jeu affiché au
+	// démarrage : un nom du registre, ou "all" pour les agréger
+	Datasets []dataset `json:"datasets"`
+	Dataset  string    `json:"dataset"`
+}
+
+// apply applique la configuration aux réglages de l'application : touches,
+// thème, format de copie, saison balnéaire, seuils et jeux de données ;
+// dataset, s'il n'est pas vide, remplace le jeu de données configuré
+func (c config) apply(dataset string) error {
+	var err error
+	if keys, err = newKeyMap(c.Keys); err != nil {
+		return err
+	}
+	if activeTheme, err = loadTheme(c.Theme); err != nil {
+		return err
+	}
+	if defaultCopyFormat, err = parseCopyFormat(c.CopyFormat); err != nil {
+		return err
+	}
+	if bathingSeason, err = c.bathingSeason(); err != nil {
+		return err
+	}
+	if defaultThresholds, err = c.thresholds(); err != nil {
+		return err
+	}
+	defaultDataset, err = c.datasets(dataset)
+	return err
+}
+
+// refreshInterval renvoie l'intervalle configuré, ou 0 s'il est absent
+func (c config) refreshInterval() (time.Duration, error) {
+	if c.RefreshInterval == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.RefreshInterval)
+	if err != nil {
+		return 0, fmt.Errorf("refresh_interval : %w", err)
+	}
+	if d < minRefreshInterval {
+		return 0, fmt.Errorf("refresh_interval : %s est inférieur au minimum (%s)", d, minRefreshInterval)
+	}
+	return d, nil
+}
+
+// bathingSeason renvoie la saison balnéaire configurée, ou celle par défaut
+func (c config) bathingSeason() (seasonDates, error) {
+	season := bathingSeason
+	var err error
+	if c.BathingSeason.Start != "" {
+		if season.start, err = parseMonthDay(c.BathingSeason.Start); err != nil {
+			return season, fmt.Errorf("bathing_season : %w", err)
+		}
+	}
+	if c.BathingSeason.End != "" {
+		if season.end, err = parseMonthDay(c.BathingSeason.End); err != nil {
+			return season, fmt.Errorf("bathing_season : %w", err)
+		}
+	}
+	return season, nil
+}
+
+// thresholds renvoie le profil de seuils configuré, après avoir enregistré
+// le profil local s'il est défini
+func (c config) thresholds() (thresholdProfile, error) {
+	if c.LocalThresholds != nil {
+		local := withName(*c.LocalThresholds, "local")
+		if local.Label == "" {
+			local.Label = "Profil local"
+		}
+		if err := local.validate(); err != nil {
+			return thresholdProfile{}, fmt.Errorf("local_thresholds : %w", err)
+		}
+		localThresholds = &local
+	}
+	p, err := loadThresholds(c.Thresholds)
+	if err != nil {
+		return p, fmt.Errorf("thresholds : %w", err)
+	}
+	return p, nil
+}
+
+// datasets enregistre les jeux de données configurés et renvoie la sélection
+// au démarrage ; selection, s'il n'est pas vide, remplace celle du fichier
+func (c config) datasets(selection string) (string, error) {
+	dir := "."
+	if path, err := configPath(); err == nil {
+		dir = filepath.Dir(path)
+	}
+	if err := registerDatasets(c.Datasets, dir); err != nil {
+		return "", fmt.Errorf("datasets : %w", err)
+	}
+	name, err := selectDataset(cmp.Or(selection, c.Dataset))
+	if err != nil {
+		return "", fmt.Errorf("dataset : %w", err)
+	}
+	return name, nil
+}
+
+// covariates charge les covariables configurées ; un fichier illisible
+// n'empêche pas le chargement des autres et donne une erreur par fichier
+func (c config) covariates() ([]covariate, []error) {
+	dir := "."
+	if path, err := configPath(); err == nil {
+		dir = filepath.Dir(path)
+	}
+	var loaded []covariate
+	var errs []error
+	for _, cc := range c.Covariates {
+		cov, err := loadCovariate(cc, dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s : %w", cmp.Or(cc.Name, cc.File), err))
+			continue
+		}
+		loaded = append(loaded, cov)
+	}
+	return loaded, errs
+}
+
+// configPath renvoie le chemin du fichier de configuration
+// ($XDG_CONFIG_HOME/edb-tui/config.json sous Linux)
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "edb-tui", "config.json"), nil
+}
+
+// loadConfig lit le fichier de configuration ; son absence n'est pas une erreur
+func loadConfig() (config, error) {
+	var cfg config
+	path, err := configPath()
+	if err != nil {
+		return cfg, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s : %w", path, err)
+	}
+	return cfg, nil
+}
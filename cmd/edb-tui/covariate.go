@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fenêtres par défaut des corrélations : cumul ou moyenne de la covariable
+// sur les 24, 48 et 72 heures qui précèdent chaque prélèvement
+var defaultCovariateLags = []time.Duration{24 * time.Hour, 48 * time.Hour, 72 * time.Hour}
+
+// Nombre minimum de prélèvements couverts par la covariable pour calculer
+// une corrélation
+const covariateMinSamples = 5
+
+// Formats de date acceptés dans les fichiers de covariables, en plus de ceux
+// des prélèvements
+var covariateTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// covariateConfig décrit un fichier CSV local de covariable environnementale
+// (pluie, marée, vent) dans la configuration
+type covariateConfig struct {
+	Name  string `json:"name"`         // libellé affiché, par exemple "Pluie"
+	File  string `json:"file"`         // chemin du CSV, relatif au dossier de configuration
+	Date  string `json:"date_column"`  // colonne de date, "date" par défaut
+	Value string `json:"value_column"` // colonne de valeur, la deuxième par défaut
+	Unit  string `json:"unit"`         // unité affichée, par exemple "mm"
+	// Agrégation sur la fenêtre : "sum" (cumul, par défaut) ou "mean"
+	Aggregate string `json:"aggregate"`
+	// Fenêtres des corrélations, en durées Go ; par défaut 24h, 48h et 72h
+	Lags []string `json:"lags"`
+}
+
+// covariate est une série environnementale importée, dans l'ordre chronologique
+type covariate struct {
+	name   string
+	unit   string
+	mean   bool // moyenne sur la fenêtre plutôt que cumul
+	lags   []time.Duration
+	points []chartPoint
+}
+
+// covariates sont les covariables chargées au démarrage
+var covariates []covariate
+
+// loadCovariate lit le fichier d'une covariable ; le séparateur (virgule ou
+// point-virgule) est détecté sur l'en-tête et la virgule décimale acceptée
+func loadCovariate(cfg covariateConfig, dir string) (covariate, error) {
+	c := covariate{name: cfg.Name, unit: cfg.Unit, lags: defaultCovariateLags}
+	switch cfg.Aggregate {
+	case "", "sum":
+	case "mean":
+		c.mean = true
+	default:
+		return c, fmt.Errorf("agrégation inconnue %q (sum ou mean)", cfg.Aggregate)
+	}
+	if len(cfg.Lags) > 0 {
+		c.lags = nil
+		for _, s := range cfg.Lags {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				return c, fmt.Errorf("fenêtre %q invalide", s)
+			}
+			c.lags = append(c.lags, d)
+		}
+	}
+	path := cfg.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	r := csv.NewReader(strings.NewReader(string(b)))
+	r.Comma = csvComma(b)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return c, err
+	}
+	if len(records) < 2 {
+		return c, fmt.Errorf("%s : aucune donnée", path)
+	}
+	dateCol, valueCol := cfg.Date, cfg.Value
+	if dateCol == "" {
+		dateCol = "date"
+	}
+	dateIdx := columnIndex(records[0], dateCol)
+	valueIdx := 1
+	if valueCol != "" {
+		valueIdx = columnIndex(records[0], valueCol)
+	}
+	if dateIdx < 0 || valueIdx < 0 {
+		return c, fmt.Errorf("%s : colonnes %q et %q attendues", path, dateCol, valueCol)
+	}
+	for _, rec := range records[1:] {
+		if dateIdx >= len(rec) || valueIdx >= len(rec) {
+			continue
+		}
+		when := parseCovariateTime(strings.TrimSpace(rec[dateIdx]))
+		v, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(rec[valueIdx]), ",", ".", 1), 64)
+		if when.IsZero() || err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		c.points = append(c.points, chartPoint{when, v})
+	}
+	if len(c.points) == 0 {
+		return c, fmt.Errorf("%s : aucune ligne lisible", path)
+	}
+	slices.SortFunc(c.points, func(a, b chartPoint) int { return a.when.Compare(b.when) })
+	if c.name == "" {
+		c.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return c, nil
+}
+
+// parseCovariateTime interprète la date d'une ligne de covariable
+func parseCovariateTime(s string) time.Time {
+	for _, layout := range covariateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return parseSampleTime(s)
+}
+
+// window renvoie le cumul ou la moyenne de la covariable sur [t-lag, t). Le
+// prélèvement n'est couvert que si la série commence avant la fenêtre et se
+// poursuit jusqu'à la veille du prélèvement au moins.
+func (c covariate) window(t time.Time, lag time.Duration) (float64, bool) {
+	from := t.Add(-lag)
+	if len(c.points) == 0 || c.points[0].when.After(from) || c.points[len(c.points)-1].when.Before(t.Add(-24*time.Hour)) {
+		return 0, false
+	}
+	start, _ := slices.BinarySearchFunc(c.points, from, func(p chartPoint, t time.Time) int { return p.when.Compare(t) })
+	sum, n := 0.0, 0
+	for _, p := range c.points[start:] {
+		if !p.when.Before(t) {
+			break
+		}
+		sum += p.value
+		n++
+	}
+	if !c.mean {
+		return sum, true
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// laggedCorrelation est la corrélation entre la covariable agrégée sur une
+// fenêtre et un indicateur
+type laggedCorrelation struct {
+	lag   time.Duration
+	rho   float64 // corrélation de rang de Spearman
+	count int     // prélèvements couverts
+}
+
+// correlate calcule la corrélation de Spearman entre la covariable agrégée
+// sur chaque fenêtre et les valeurs d'un indicateur d'un point
+func (c covariate) correlate(samples []sample, colName string) []laggedCorrelation {
+	var result []laggedCorrelation
+	for _, lag := range c.lags {
+		var xs, ys []float64
+		for _, s := range samples {
+			m := s.measure(colName)
+			if !m.ok() || s.when.IsZero() {
+				continue
+			}
+			if x, ok := c.window(s.when, lag); ok {
+				xs, ys = append(xs, x), append(ys, m.number())
+			}
+		}
+		lc := laggedCorrelation{lag: lag, count: len(xs), rho: math.NaN()}
+		if lc.count >= covariateMinSamples {
+			lc.rho = pearson(ranks(xs), ranks(ys))
+		}
+		result = append(result, lc)
+	}
+	return result
+}
+
+// ranks renvoie le rang de chaque valeur, les ex aequo recevant leur rang moyen
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmpFloat(values[a], values[b]) })
+	r := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		for k := i; k <= j; k++ {
+			r[order[k]] = float64(i+j)/2 + 1
+		}
+		i = j + 1
+	}
+	return r
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// pearson renvoie le coefficient de corrélation linéaire, NaN si l'une des
+// séries est constante
+func pearson(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i] / n
+		my += ys[i] / n
+	}
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// formatLag formate une fenêtre : "48 h", ou "7 j" pour un nombre entier de jours
+// au-delà de 72 heures
+func formatLag(d time.Duration) string {
+	if h := d.Hours(); h > 72 && math.Mod(h, 24) == 0 {
+		return tr("%.0f j", h/24)
+	}
+	return tr("%.0f h", d.Hours())
+}
+
+// correlationLines décrit les corrélations d'un point avec une covariable,
+// une ligne par indicateur
+func (c covariate) correlationLines(p pointHistory) []string {
+	aggregate := tr("cumul")
+	if c.mean {
+		aggregate = tr("moyenne")
+	}
+	lines := []string{tr("%s (%s avant le prélèvement, corrélation de Spearman) :", c.name, aggregate)}
+	for _, colName := range []string{"E. coli", "Enté."} {
+		var parts []string
+		count := 0
+		for _, lc := range c.correlate(p.samples, colName) {
+			rho := "–"
+			if !math.IsNaN(lc.rho) {
+				rho = fmt.Sprintf("%+.2f", lc.rho)
+			}
+			parts = append(parts, formatLag(lc.lag)+" "+rho)
+			count = max(count, lc.count)
+		}
+		lines = append(lines, tr("%s : ρ %s (n=%d)", tr(colName), strings.Join(parts, " · "), count))
+	}
+	return lines
+}
+
+// overlay renvoie la covariable sur l'intervalle [from, to], à tracer sous
+// les courbes du graphique
+func (c covariate) overlay(from, to time.Time) []chartPoint {
+	var points []chartPoint
+	for _, p := range c.points {
+		if !p.when.Before(from) && !p.when.After(to) {
+			points = append(points, p)
+		}
+	}
+	return points
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// allDatasets désigne l'affichage agrégé de tous les jeux de données
+const allDatasets = "all"
+
+// Colonnes attendues par l'application dans les prélèvements et dans le
+// résumé, auxquelles la correspondance d'un jeu de données associe les
+// colonnes de ses CSV
+var (
+	detailsColumns = []string{
+		"site", "point_de_prelevement", "date", "heure", "e_coli_npp_100ml",
+		"enterocoques_npp_100ml", "id_point_prelevement", "desc_point_prelevement",
+	}
+	resumeColumns  = []string{"plage", "etat_sanitaire"}
+	datasetColumns = slices.Concat(detailsColumns, resumeColumns)
+)
+
+// communeColumn est la colonne ajoutée aux CSV agrégés : commune de chaque ligne
+const communeColumn = "commune"
+
+// dataset décrit une source de données d'une commune dans la configuration
+type dataset struct {
+	Name    string `json:"name"`    // identifiant, par exemple "mont-dore"
+	Label   string `json:"label"`   // nom de la commune affiché dans le titre
+	Source  string `json:"source"`  // attribution affichée sous le titre
+	Resume  string `json:"resume"`  // URL ou chemin du résumé par plage, facultatif
+	Details string `json:"details"` // URL ou chemin des prélèvements
+	Page    string `json:"page"`    // page des données, encodée dans le QR code "Données source"
+	Points  string `json:"points"`  // positions des points pour la carte et le QR code, facultatif
+	// Colonnes des CSV par colonne attendue, par exemple {"site": "plage"}
+	Columns map[string]string `json:"columns"`
+}
+
+// noumeaDataset est le jeu de données intégré, publié sur GitHub
+var noumeaDataset = dataset{
+	Name:    "noumea",
+	Label:   "Nouméa",
+	Source:  "github.com/adriens/edb-noumea-data",
+	Resume:  csvURL,
+	Details: detailsURL,
+	Page:    sourceDataURL,
+}
+
+// datasets est le registre des jeux de données, le jeu intégré en premier
+var datasets = []dataset{noumeaDataset}
+
+// defaultDataset est le jeu de données choisi au démarrage (configuration ou
+// --dataset) : nom d'un jeu ou allDatasets. Chaque modèle garde ensuite sa
+// propre sélection.
+var defaultDataset = noumeaDataset.Name
+
+// validate vérifie un jeu de données de la configuration et complète les
+// valeurs par défaut ; les chemins relatifs partent du dossier dir
+func (d dataset) validate(dir string) (dataset, error) {
+	switch {
+	case d.Name == "":
+		return d, fmt.Errorf("nom manquant")
+	case d.Name == allDatasets:
+		return d, fmt.Errorf("%q est réservé à l'affichage agrégé", allDatasets)
+	case d.Details == "":
+		return d, fmt.Errorf("%s : details manquant", d.Name)
+	}
+	for name := range d.Columns {
+		if !slices.Contains(datasetColumns, name) {
+			return d, fmt.Errorf("%s : colonne %q inconnue (colonnes : %s)", d.Name, name, strings.Join(datasetColumns, ", "))
+		}
+	}
+	d.Details = sourceURL(d.Details, dir)
+	if d.Resume != "" {
+		d.Resume = sourceURL(d.Resume, dir)
+	}
+	if d.Label == "" {
+		d.Label = d.Name
+	}
+	if d.Source == "" {
+		u, _ := url.Parse(d.Details)
+		d.Source = strings.TrimPrefix(u.Host+path.Dir(u.Path), "/")
+	}
+	if d.Page == "" {
+		d.Page = d.Details
+	}
+	if d.Points != "" && !filepath.IsAbs(d.Points) {
+		d.Points = filepath.Join(dir, d.Points)
+	}
+	return d, nil
+}
+
+// sourceURL renvoie l'URL d'une source : telle quelle si elle en a le schéma,
+// sinon l'URL file:// du chemin, relatif au dossier dir
+func sourceURL(s, dir string) string {
+	if strings.Contains(s, "://") {
+		return s
+	}
+	if !filepath.IsAbs(s) {
+		s = filepath.Join(dir, s)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(s)}).String()
+}
+
+// registerDatasets ajoute au registre les jeux de données de la
+// configuration ; un jeu portant le nom du jeu intégré le remplace
+func registerDatasets(configured []dataset, dir string) error {
+	for _, d := range configured {
+		d, err := d.validate(dir)
+		if err != nil {
+			return err
+		}
+		if d.Points != "" {
+			locations, err := loadPointLocations(d.Points)
+			if err != nil {
+				return fmt.Errorf("%s : points : %w", d.Name, err)
+			}
+			pointLocations[d.Name] = locations
+		}
+		if i := slices.IndexFunc(datasets, func(r dataset) bool { return r.Name == d.Name }); i >= 0 {
+			datasets[i] = d
+			continue
+		}
+		datasets = append(datasets, d)
+	}
+	return nil
+}
+
+// selectDataset vérifie le nom d'un jeu de données ; "" désigne le jeu intégré
+func selectDataset(name string) (string, error) {
+	if name == "" {
+		return noumeaDataset.Name, nil
+	}
+	if name == allDatasets || slices.ContainsFunc(datasets, func(d dataset) bool { return d.Name == name }) {
+		return name, nil
+	}
+	var names []string
+	for _, d := range datasets {
+		names = append(names, d.Name)
+	}
+	return "", fmt.Errorf("jeu de données inconnu %q (jeux : %s, %s)", name, strings.Join(names, ", "), allDatasets)
+}
+
+// selectedDatasets renvoie les jeux de données d'une sélection
+func selectedDatasets(name string) []dataset {
+	if name == allDatasets {
+		return datasets
+	}
+	for _, d := range datasets {
+		if d.Name == name {
+			return []dataset{d}
+		}
+	}
+	return nil
+}
+
+// datasetLabel renvoie le nom des communes d'une sélection
+func datasetLabel(name string) string {
+	var labels []string
+	for _, d := range selectedDatasets(name) {
+		labels = append(labels, d.Label)
+	}
+	return strings.Join(labels, ", ")
+}
+
+// datasetSource renvoie l'attribution des données d'une sélection
+func datasetSource(name string) string {
+	var sources []string
+	for _, d := range selectedDatasets(name) {
+		if !slices.Contains(sources, d.Source) {
+			sources = append(sources, d.Source)
+		}
+	}
+	return strings.Join(sources, ", ")
+}
+
+// datasetOf renvoie le jeu de données d'une commune affichée ; sans commune
+// (un seul jeu affiché), le premier jeu de la sélection
+func datasetOf(commune, selection string) dataset {
+	selected := selectedDatasets(selection)
+	for _, d := range selected {
+		if d.Label == commune {
+			return d
+		}
+	}
+	if len(selected) > 0 {
+		return selected[0]
+	}
+	return noumeaDataset
+}
+
+// nextDataset renvoie la sélection suivante de la touche de changement :
+// chaque jeu de données, puis l'affichage agrégé
+func nextDataset(name string) string {
+	if name == allDatasets {
+		return datasets[0].Name
+	}
+	i := slices.IndexFunc(datasets, func(d dataset) bool { return d.Name == name })
+	if i+1 < len(datasets) {
+		return datasets[i+1].Name
+	}
+	return allDatasets
+}
+
+// datasetFile est un CSV à récupérer pour un jeu de données
+type datasetFile struct {
+	dataset int    // index dans la sélection
+	resume  bool   // résumé par plage, sinon prélèvements
+	url     string // URL http(s) ou file://
+}
+
+// datasetFiles renvoie les fichiers d'une sélection dans l'ordre de
+// récupération : pour chaque jeu, résumé puis prélèvements
+func datasetFiles(selected []dataset) []datasetFile {
+	var files []datasetFile
+	for i, d := range selected {
+		if d.Resume != "" {
+			files = append(files, datasetFile{dataset: i, resume: true, url: d.Resume})
+		}
+		files = append(files, datasetFile{dataset: i, url: d.Details})
+	}
+	return files
+}
+
+// label renvoie le nom du fichier affiché pendant la récupération, préfixé
+// du jeu de données quand plusieurs sont récupérés
+func (f datasetFile) label(selected []dataset) string {
+	name := path.Base(f.url)
+	if len(selected) > 1 {
+		name = selected[f.dataset].Name + "/" + name
+	}
+	return name
+}
+
+// normalize renomme les colonnes d'un CSV selon la correspondance du jeu de
+// données, pour les colonnes attendues names ; la première de names est
+// placée en tête (le site des prélèvements, la plage du résumé)
+func (d dataset) normalize(records [][]string, names []string) [][]string {
+	if len(records) == 0 {
+		return records
+	}
+	header := slices.Clone(records[0])
+	for j, col := range header {
+		for _, name := range names {
+			if source, ok := d.Columns[name]; ok && col == source {
+				header[j] = name
+			}
+		}
+	}
+	records[0] = header
+	idx := columnIndex(header, names[0])
+	if idx <= 0 {
+		return records
+	}
+	for i, row := range records {
+		if idx < len(row) {
+			row = slices.Clone(row)
+			cell := row[idx]
+			copy(row[1:idx+1], row[:idx])
+			row[0] = cell
+			records[i] = row
+		}
+	}
+	return records
+}
+
+// resumeFromDetails construit le résumé d'un jeu de données qui n'en publie
+// pas : une ligne par plage, sans état sanitaire
+func resumeFromDetails(details [][]string) [][]string {
+	resume := [][]string{{"plage"}}
+	var seen []string
+	for _, row := range details[1:] {
+		if len(row) > 0 && !slices.Contains(seen, row[0]) {
+			seen = append(seen, row[0])
+			resume = append(resume, []string{row[0]})
+		}
+	}
+	return resume
+}
+
+// canonicalColumn ramène les variantes de nom d'un indicateur à un seul nom,
+// pour aligner les colonnes de CSV de versions différentes
+func canonicalColumn(col string) string {
+	switch {
+	case slices.Contains(ecoliColumns, col):
+		return ecoliColumns[1]
+	case slices.Contains(enteColumns, col):
+		return enteColumns[1]
+	}
+	return col
+}
+
+// mergeTables agrège les CSV de plusieurs jeux de données : colonnes réunies
+// dans l'ordre de leur première apparition, puis la commune de chaque ligne.
+// Les identifiants de points des jeux suivants le premier sont préfixés du
+// nom du jeu pour rester uniques ; ceux du premier (en général le jeu intégré)
+// gardent la correspondance avec les positions intégrées de la carte.
+func mergeTables(tables [][][]string, selected []dataset) [][]string {
+	if len(tables) == 1 {
+		return tables[0]
+	}
+	var header []string
+	for _, t := range tables {
+		for _, col := range t[0] {
+			if col = canonicalColumn(col); !slices.Contains(header, col) {
+				header = append(header, col)
+			}
+		}
+	}
+	idIdx := slices.Index(header, "id_point_prelevement")
+	merged := [][]string{append(header, communeColumn)}
+	for i, t := range tables {
+		positions := make([]int, len(t[0]))
+		for j, col := range t[0] {
+			positions[j] = slices.Index(header, canonicalColumn(col))
+		}
+		for _, row := range t[1:] {
+			out := make([]string, len(header)+1)
+			for j, cell := range row {
+				if j < len(positions) {
+					out[positions[j]] = cell
+				}
+			}
+			if i > 0 && idIdx >= 0 && out[idIdx] != "" {
+				out[idIdx] = selected[i].Name + "/" + out[idIdx]
+			}
+			out[len(header)] = selected[i].Label
+			merged = append(merged, out)
+		}
+	}
+	return merged
+}
+
+// fetchDetails récupère les seuls prélèvements d'une sélection, sans
+// avancement, pour les commandes sans interface interactive
+func fetchDetails(selection string) ([][]string, error) {
+	selected := selectedDatasets(selection)
+	tables := make([][][]string, len(selected))
+	for i, d := range selected {
+		records, err := fetchCSVData(d.Details, func(int64, int64, bool) {})
+		if err != nil {
+			return nil, err
+		}
+		tables[i] = d.normalize(records, detailsColumns)
+	}
+	return mergeTables(tables, selected), nil
+}
+
+// switchDataset passe au jeu de données suivant et relance la récupération ;
+// les données affichées restent celles de l'ancien jeu jusqu'à leur arrivée
+func (m Model) switchDataset(now time.Time) (Model, tea.Cmd) {
+	m.dataset = nextDataset(m.dataset)
+	m = m.addLog(slog.LevelInfo, tr("Commune : %s", datasetLabel(m.dataset)), "jeu", m.dataset)
+	if m.fetching {
+		// La récupération en cours sera ignorée à son arrivée, puis relancée
+		return m, nil
+	}
+	return m.startFetch(now)
+}
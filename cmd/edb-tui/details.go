@@ -0,0 +1,680 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// idColumn est la colonne technique gardée dans les lignes des détails :
+// l'identifiant du point distingue deux points de même plage et de même
+// description (jeux agrégés). Elle n'est ni affichée ni copiée.
+const idColumn = "id_point_prelevement"
+
+// detailRows transforme le CSV des détails pour l'affichage : suppression des
+// colonnes techniques (sauf idColumn), fusion date/heure et renommage des
+// en-têtes. La première ligne renvoyée est l'en-tête.
+func detailRows(details [][]string) [][]string {
+	if len(details) == 0 {
+		return nil
+	}
+	// Trouve les index des colonnes à traiter
+	removeIdx := -1
+	dateIdx := -1
+	heureIdx := -1
+	descIdx := -1
+	for idx, col := range details[0] {
+		if col == "point_de_prelevement" {
+			removeIdx = idx
+		}
+		if col == "date" {
+			dateIdx = idx
+		}
+		if col == "heure" {
+			heureIdx = idx
+		}
+		if col == "desc_point_prelevement" {
+			descIdx = idx
+		}
+	}
+
+	// Filtre, fusionne et insère la colonne desc_point_prelevement après site
+	filtered := make([][]string, len(details))
+	for i, row := range details {
+		filteredRow := make([]string, 0, len(row))
+		for j, cell := range row {
+			if j == removeIdx {
+				continue
+			}
+			if j == dateIdx {
+				// Fusionne date et heure
+				dateVal := cell
+				heureVal := ""
+				if heureIdx != -1 && heureIdx < len(row) {
+					heureVal = row[heureIdx]
+				}
+				if i == 0 {
+					filteredRow = append(filteredRow, "Date")
+				} else {
+					filteredRow = append(filteredRow, dateVal+" "+heureVal)
+				}
+				continue
+			}
+			if j == heureIdx {
+				continue // déjà fusionné
+			}
+			// Ajoute la colonne desc_point_prelevement juste après site, avec renommage
+			if j == 0 && descIdx != -1 && descIdx < len(row) {
+				if i == 0 {
+					filteredRow = append(filteredRow, "Site")
+					filteredRow = append(filteredRow, "Point de prélèvement")
+				} else {
+					filteredRow = append(filteredRow, siteName(cell))
+					filteredRow = append(filteredRow, capitalize(row[descIdx]))
+				}
+				continue
+			}
+			if j == descIdx {
+				continue // déjà inséré
+			}
+			// Renomme l'en-tête 'enterocoques_npp_100ml' ou 'ent_npp_100ml' en 'Enté.'
+			if i == 0 && (cell == "enterocoques_npp_100ml" || cell == "ent_npp_100ml") {
+				filteredRow = append(filteredRow, "Enté.")
+				continue
+			}
+			// Renomme l'en-tête 'ec_npp_100ml' en 'E. coli'
+			if i == 0 && (cell == "e_coli_npp_100ml" || cell == "ec_npp_100ml") {
+				filteredRow = append(filteredRow, "E. coli")
+				continue
+			}
+			// Renomme l'en-tête 'commune' des données agrégées
+			if i == 0 && cell == communeColumn {
+				filteredRow = append(filteredRow, "Commune")
+				continue
+			}
+			filteredRow = append(filteredRow, cell)
+		}
+		filtered[i] = filteredRow
+	}
+
+	// Toutes les lignes doivent avoir le même nombre de colonnes
+	numCols := len(filtered[0])
+	for i := range filtered {
+		for k := len(filtered[i]); k < numCols; k++ {
+			filtered[i] = append(filtered[i], "")
+		}
+	}
+	return filtered
+}
+
+// withPointColumn ajoute aux lignes des détails une colonne calculée pour le
+// point de prélèvement de chaque ligne, identifié par sa clé (rowPointKey)
+func withPointColumn(rows [][]string, name string, value func(key string) string) [][]string {
+	if len(rows) == 0 {
+		return rows
+	}
+	header := rows[0]
+	rows[0] = append(rows[0], name)
+	for i, row := range rows[1:] {
+		rows[i+1] = append(row, value(rowPointKey(header, row)))
+	}
+	return rows
+}
+
+// detailsTab est l'onglet Détails : tableau des prélèvements triable, avec la
+// box de détail de la ligne sélectionnée
+type detailsTab struct {
+	width      int
+	height     int
+	details    [][]string // CSV brut des détails
+	shown      [][]string // lignes du CSV retenues par la période, en-tête compris
+	sortColumn string     // colonne de tri des détails ("" = ordre du CSV)
+	sortDesc   bool       // sens du tri (true=décroissant, false=croissant)
+	selected   int        // ligne sélectionnée dans le tableau des détails
+	offset     int        // première ligne de données affichée (défilement)
+	copyFormat int        // format de copie dans le presse-papiers
+
+	// Classement 2006/7/CE et tendance de chaque point, par plage et point
+	// de prélèvement
+	classes    map[string]classification
+	trends     map[string]pointTrend
+	marked     map[string]bool  // points marqués pour la comparaison
+	period     sampleFilter     // période choisie
+	thresholds thresholdProfile // profil de seuils choisi
+}
+
+func newDetailsTab() detailsTab {
+	return detailsTab{selected: 1, copyFormat: defaultCopyFormat, marked: map[string]bool{}, thresholds: defaultThresholds}
+}
+
+func (t detailsTab) Init() tea.Cmd { return nil }
+
+func (t detailsTab) title() string { return tr("Détails") }
+
+func (t detailsTab) shortcuts() []shortcut {
+	return []shortcut{
+		newShortcut("Trier E. coli", keys.SortEcoli),
+		newShortcut("Trier Enté.", keys.SortEnte),
+		newShortcut("Sélection détail", keys.Up, keys.Down),
+		newShortcut("Copier ligne / tableau", keys.Copy, keys.CopyAll),
+		newShortcut("Format de copie", keys.CopyFmt),
+		newShortcut("QR code du point", keys.QR),
+		newShortcut("Marquer / comparer", keys.Mark, keys.Compare),
+		{bindings: []key.Binding{keys.Top, keys.Bottom}, desc: "Première / dernière ligne", hidden: true},
+	}
+}
+
+func (t detailsTab) Update(msg tea.Msg) (tabModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+		return t.scrollToSelection(), nil
+	case dataMsg:
+		// Classement et tendance portent sur tout l'historique des points,
+		// quelle que soit la période affichée
+		t.details = msg.details
+		t.shown = t.period.rows(msg.details)
+		t.classes = classifyPoints(msg.details)
+		t.trends = trendPoints(msg.details)
+		return t.clampSelection(), nil
+	case thresholdsMsg:
+		t.thresholds = msg.profile
+		return t, nil
+	case periodMsg:
+		t.period = msg.filter
+		t.shown = t.period.rows(t.details)
+		return t.clampSelection(), nil
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.SortEcoli):
+			return t.toggleSort("E. coli"), nil
+		case key.Matches(msg, keys.SortEnte):
+			return t.toggleSort("Enté."), nil
+		case key.Matches(msg, keys.Up):
+			if len(t.shown) > 1 && t.selected > 1 {
+				t.selected--
+			}
+			return t.scrollToSelection(), nil
+		case key.Matches(msg, keys.Down):
+			if len(t.shown) > 1 && t.selected < len(t.shown)-1 {
+				t.selected++
+			}
+			return t.scrollToSelection(), nil
+		case key.Matches(msg, keys.Top):
+			t.selected = 1
+			return t.scrollToSelection(), nil
+		case key.Matches(msg, keys.Bottom):
+			if len(t.shown) > 1 {
+				t.selected = len(t.shown) - 1
+			}
+			return t.scrollToSelection(), nil
+		case key.Matches(msg, keys.Copy):
+			rows := t.rows()
+			if t.selected <= 0 || t.selected >= len(rows) {
+				return t, nil
+			}
+			return t, copyCmd(t.thresholds, rows[0], rows[t.selected:t.selected+1], t.copyFormat)
+		case key.Matches(msg, keys.CopyAll):
+			rows := t.rows()
+			if len(rows) < 2 {
+				return t, nil
+			}
+			return t, copyCmd(t.thresholds, rows[0], rows[1:], t.copyFormat)
+		case key.Matches(msg, keys.QR):
+			rows := t.rows()
+			if t.selected <= 0 || t.selected >= len(rows) {
+				return t, nil
+			}
+			p := rowPoint(rows[0], rows[t.selected])
+			return t, func() tea.Msg { return qrMsg{p} }
+		case key.Matches(msg, keys.Mark):
+			if k := t.selectedKey(); k != "" {
+				if t.marked[k] {
+					delete(t.marked, k)
+				} else {
+					t.marked[k] = true
+				}
+			}
+			return t, nil
+		case key.Matches(msg, keys.Compare):
+			points := markedPoints(t.details, t.marked)
+			return t, func() tea.Msg { return compareMsg{points} }
+		case key.Matches(msg, keys.CopyFmt):
+			t.copyFormat = (t.copyFormat + 1) % len(copyFormatNames)
+			format := t.copyFormat
+			return t, func() tea.Msg { return copyFormatMsg(format) }
+		}
+	case tea.MouseMsg:
+		return t.handleMouse(msg), nil
+	}
+	return t, nil
+}
+
+// clampSelection garde la ligne sélectionnée dans le tableau affiché
+func (t detailsTab) clampSelection() detailsTab {
+	if t.selected >= len(t.shown) {
+		t.selected = len(t.shown) - 1
+	}
+	if t.selected < 1 {
+		t.selected = 1
+	}
+	return t.scrollToSelection()
+}
+
+func (t detailsTab) View() string {
+	if len(t.shown) < 2 && len(t.details) >= 2 {
+		return tr("Aucun prélèvement sur la période (%s).", t.period.label)
+	}
+	section, _ := t.renderDetailsSection(contentLayout(t.width, t.height), t.height)
+	return lipgloss.PlaceHorizontal(t.width, lipgloss.Center, section)
+}
+
+// selectedSite renvoie la plage de la ligne sélectionnée, ou ""
+func (t detailsTab) selectedSite() string {
+	rows := t.rows()
+	if t.selected <= 0 || t.selected >= len(rows) {
+		return ""
+	}
+	if col := columnIndex(rows[0], "Site"); col >= 0 {
+		return rows[t.selected][col]
+	}
+	return ""
+}
+
+// selectedKey renvoie la clé du point de la ligne sélectionnée, ou ""
+func (t detailsTab) selectedKey() string {
+	rows := t.rows()
+	if t.selected <= 0 || t.selected >= len(rows) {
+		return ""
+	}
+	return rowPointKey(rows[0], rows[t.selected])
+}
+
+// rowPointKey identifie le point d'une ligne des détails comme groupByPoint :
+// par son identifiant, à défaut par sa plage et son point de prélèvement
+func rowPointKey(header, row []string) string {
+	if columnIndex(header, "Site") < 0 {
+		return ""
+	}
+	return rowPoint(header, row).key
+}
+
+// rows renvoie le tableau des détails de la période, transformé et trié
+// selon la colonne choisie ; c'est aussi le tableau copié
+func (t detailsTab) rows() [][]string {
+	filtered := withPointColumn(detailRows(t.shown), "Classement", func(key string) string {
+		if c, ok := t.classes[key]; ok {
+			return classNames[c.class]
+		}
+		return classNames[classNone]
+	})
+	filtered = withPointColumn(filtered, "Tendance", func(key string) string {
+		return trendNames[t.trends[key].kind]
+	})
+	// Tri selon la colonne choisie (touches e/n ou clic sur l'en-tête)
+	if t.sortColumn != "" {
+		sortRowsByColumn(filtered, t.sortColumn, t.sortDesc)
+	}
+	return filtered
+}
+
+// toggleSort active le tri des détails sur une colonne, ou en inverse le sens
+// si la colonne est déjà triée
+func (t detailsTab) toggleSort(colName string) detailsTab {
+	if t.sortColumn == colName {
+		t.sortDesc = !t.sortDesc
+	} else {
+		t.sortColumn = colName
+		t.sortDesc = false
+	}
+	return t
+}
+
+// renderDetailsSection affiche le tableau des détails et la box de détail de la
+// ligne sélectionnée, côte à côte sur les écrans larges, empilés sinon. La box
+// est omise si la hauteur disponible ne permet pas d'afficher quelques lignes.
+func (t detailsTab) renderDetailsSection(l layout, height int) (string, tableHits) {
+	filtered := t.rows()
+	if len(filtered) == 0 {
+		return "", tableHits{}
+	}
+	// Bordure (2) + en-tête (1)
+	maxRows := height - 3
+	if t.selected <= 0 || t.selected >= len(filtered) {
+		return t.renderDetailsTable(filtered, l, maxRows)
+	}
+	if l.sideBySide {
+		detailsTable, hits := t.renderDetailsTable(filtered, l, maxRows)
+		boxWidth := l.contentWidth - hits.width - 2
+		if boxWidth >= minBoxWidth {
+			detailBox := lipgloss.NewStyle().MaxHeight(height).Render(renderDetailBox(t.thresholds, filtered[0], filtered[t.selected], boxWidth, t.pointNotes(filtered)))
+			return lipgloss.JoinHorizontal(lipgloss.Top, detailsTable, "  ", detailBox), hits
+		}
+	}
+	boxWidth := l.width / 2
+	if boxWidth < minBoxWidth {
+		boxWidth = minBoxWidth
+	}
+	if boxWidth > l.contentWidth {
+		boxWidth = l.contentWidth
+	}
+	detailBox := renderDetailBox(t.thresholds, filtered[0], filtered[t.selected], boxWidth, t.pointNotes(filtered))
+	// Box empilée sous le tableau, séparée par une ligne vide
+	if stackedRows := maxRows - lipgloss.Height(detailBox) - 1; stackedRows >= minVisibleRows {
+		detailsTable, hits := t.renderDetailsTable(filtered, l, stackedRows)
+		return lipgloss.JoinVertical(lipgloss.Center, detailsTable, "", detailBox), hits
+	}
+	return t.renderDetailsTable(filtered, l, maxRows)
+}
+
+// pointNotes renvoie l'explication du classement et de la tendance du point
+// de la ligne sélectionnée
+func (t detailsTab) pointNotes(filtered [][]string) []string {
+	key := rowPointKey(filtered[0], filtered[t.selected])
+	if key == "" {
+		return nil
+	}
+	var notes []string
+	if c, ok := t.classes[key]; ok {
+		notes = append(notes, c.explain()...)
+	}
+	if trend, ok := t.trends[key]; ok {
+		notes = append(notes, trend.explain()...)
+	}
+	return notes
+}
+
+// renderDetailsTable affiche le tableau des détails en masquant les colonnes de
+// faible priorité, en tronquant les cellules selon la largeur disponible et en
+// limitant le nombre de lignes affichées à maxRows (fenêtre de défilement)
+func (t detailsTab) renderDetailsTable(filtered [][]string, l layout, maxRows int) (string, tableHits) {
+	var visible []int
+	for j, colName := range filtered[0] {
+		if colName != idColumn && !l.hiddenColumns[colName] {
+			visible = append(visible, j)
+		}
+	}
+	// Largeurs calculées sur le texte brut (sans style)
+	dColWidths := make([]int, len(visible))
+	for rowIdx := range filtered {
+		for k, j := range visible {
+			if w := runewidth.StringWidth(t.tableCell(filtered, rowIdx, j)); w > dColWidths[k] {
+				dColWidths[k] = w
+			}
+		}
+	}
+	// Bordure double (2) + "│ " et " │" en début et fin de ligne (4)
+	dColWidths = fitColumns(dColWidths, l.contentWidth, tableOverhead(len(visible))+6)
+
+	// Fenêtre de défilement : la ligne sélectionnée reste toujours visible
+	total := len(filtered) - 1
+	if maxRows < minVisibleRows {
+		maxRows = minVisibleRows
+	}
+	if maxRows > total {
+		maxRows = total
+	}
+	hits := tableHits{start: windowStart(t.offset, t.selected, maxRows, total), visible: maxRows}
+	// Le contenu commence après la bordure (1) et "│ " (2) ; chaque cellule
+	// a un padding de 1 de chaque côté et les colonnes sont séparées par " │ "
+	x := 3
+	for k, j := range visible {
+		hits.columns = append(hits.columns, hitColumn{name: filtered[0][j], x0: x, x1: x + dColWidths[k] + 2})
+		x += dColWidths[k] + 2 + 3
+	}
+
+	var dRows []string
+	for rowIdx, row := range filtered {
+		if rowIdx > 0 && (rowIdx <= hits.start || rowIdx > hits.start+hits.visible) {
+			continue
+		}
+		var dCells []string
+		for k, j := range visible {
+			cell := row[j]
+			content := padRight(truncate(t.tableCell(filtered, rowIdx, j), dColWidths[k]), dColWidths[k])
+			dCells = append(dCells, t.detailCellStyle(filtered[0][j], cell, rowIdx).Render(content))
+		}
+		dRows = append(dRows, "│ "+strings.Join(dCells, " │ ")+" │")
+	}
+	rendered := lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).BorderForeground(activeTheme.border()).Margin(0, 0).Render(strings.Join(dRows, "\n"))
+	hits.width = lipgloss.Width(rendered)
+	return rendered, hits
+}
+
+// tableCell renvoie le texte affiché d'une cellule du tableau, précédé d'un
+// repère sur le site des points marqués pour la comparaison
+func (t detailsTab) tableCell(filtered [][]string, rowIdx, j int) string {
+	text := detailCell(t.thresholds, filtered[0][j], filtered[rowIdx][j], rowIdx)
+	if rowIdx > 0 && filtered[0][j] == "Site" && t.marked[rowPointKey(filtered[0], filtered[rowIdx])] {
+		return markSymbol + " " + text
+	}
+	return text
+}
+
+// windowStart calcule le nombre de lignes de données masquées au-dessus de la
+// fenêtre de défilement, de sorte que la ligne sélectionnée (indexée à partir
+// de 1, l'en-tête étant la ligne 0) reste visible
+func windowStart(offset, selected, visible, total int) int {
+	if selected-1 < offset {
+		offset = selected - 1
+	}
+	if selected > offset+visible {
+		offset = selected - visible
+	}
+	if offset > total-visible {
+		offset = total - visible
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
+
+// detailCellStyle renvoie le style d'une cellule du tableau des détails
+func (t detailsTab) detailCellStyle(colName, cell string, rowIdx int) lipgloss.Style {
+	var style lipgloss.Style
+	if rowIdx == 0 {
+		style = lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	} else if colName == "Site" {
+		style = activeTheme.fg(activeTheme.Text).Padding(0, 1)
+		if rowIdx == t.selected {
+			style = activeTheme.selected().Bold(true).Underline(true).Padding(0, 1)
+		}
+	} else if colName == "E. coli" || colName == "Enté." {
+		style = measureStyle(t.thresholds, colName, cell).Padding(0, 1)
+	} else if colName == "Classement" {
+		style = classStyle(cell).Padding(0, 1)
+	} else if colName == "Tendance" {
+		style = trendStyle(cell).Padding(0, 1)
+	} else if colName == "Point de prélèvement" {
+		style = activeTheme.fg(activeTheme.Point).Bold(true).Padding(0, 1)
+		if rowIdx == t.selected {
+			style = style.Inherit(activeTheme.selected()).Underline(true)
+		}
+	} else if colName == "Date" && rowIdx == t.selected {
+		style = activeTheme.selected().Bold(true).Underline(true).Padding(0, 1)
+	} else {
+		style = activeTheme.fg(activeTheme.Text).Padding(0, 1)
+		if rowIdx == t.selected {
+			style = style.Inherit(activeTheme.selected()).Underline(true)
+		}
+	}
+	return style
+}
+
+// renderDetailBox génère la box de détail d'une ligne du tableau des détails,
+// suivie des notes éventuelles (explication du classement)
+func renderDetailBox(p thresholdProfile, header, detailRow []string, boxWidth int, notes []string) string {
+	// Bordure (2) + padding horizontal (2*2)
+	innerWidth := boxWidth - 6
+	var detailLines []string
+	for i, val := range detailRow {
+		label := header[i]
+		if label == idColumn {
+			continue
+		}
+		displayLabel := tr(label)
+		if label == "E. coli" {
+			displayLabel = tr("Escherichia coli")
+		} else if label == "Enté." {
+			displayLabel = tr("Entérocoques")
+		}
+		line := lipgloss.NewStyle().Bold(true).Render(displayLabel) + tr(" : ") + truncate(detailCell(p, label, val, 1), innerWidth-runewidth.StringWidth(displayLabel+tr(" : ")))
+		// Ajoute le niveau et la barre colorée pour E. coli et Enté.
+		if label == "E. coli" || label == "Enté." {
+			m := parseMeasurement(val)
+			if !m.ok() {
+				if m.invalid() {
+					line = lipgloss.NewStyle().Bold(true).Render(displayLabel) + tr(" : ") + truncate(tr("%s (valeur non numérique)", val), innerWidth-runewidth.StringWidth(displayLabel+tr(" : ")))
+				}
+				detailLines = append(detailLines, line)
+				continue
+			}
+			n := int(math.Ceil(m.number()))
+			level := m.level(p, label)
+			status := fmt.Sprintf("%s (%s %s)", m, levelSymbols[level], tr(levelNames[level]))
+			if m.censored() {
+				status = fmt.Sprintf("%s (%s %s, %s)", m, levelSymbols[level], tr(levelNames[level]), m.censorNote())
+			}
+			line = lipgloss.NewStyle().Bold(true).Render(displayLabel) + tr(" : ") + truncate(status, innerWidth-runewidth.StringWidth(displayLabel+tr(" : ")))
+			maxBarLen := innerWidth
+			if maxBarLen < 8 {
+				maxBarLen = 8
+			}
+			barLen := 0
+			_, seuilMax := p.limits(label)
+			if n > seuilMax {
+				barLen = maxBarLen
+			} else if n < 0 {
+				barLen = 0
+			} else {
+				barLen = int(float64(n) / float64(seuilMax) * float64(maxBarLen))
+				if barLen < 1 {
+					barLen = 1
+				}
+			}
+			bar := activeTheme.level(level).Align(lipgloss.Left).Width(maxBarLen).Render(strings.Repeat("━", barLen))
+			// Affiche le score sur une ligne, la barre juste en dessous
+			detailLines = append(detailLines, line)
+			detailLines = append(detailLines, bar)
+			continue
+		}
+		detailLines = append(detailLines, line)
+	}
+	if len(notes) > 0 {
+		detailLines = append(detailLines, "")
+		for _, note := range notes {
+			detailLines = append(detailLines, lipgloss.NewStyle().Faint(true).Render(truncate(note, innerWidth)))
+		}
+	}
+	return lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(activeTheme.color(activeTheme.Header)).Padding(1, 2).Width(boxWidth).Render(strings.Join(detailLines, "\n"))
+}
+
+// detailCell renvoie le texte affiché d'une cellule du tableau des détails :
+// en-tête traduit, symbole du niveau pour E. coli et Enté.
+func detailCell(p thresholdProfile, colName, cell string, rowIdx int) string {
+	if rowIdx == 0 {
+		return tr(cell)
+	}
+	switch colName {
+	case "Classement":
+		return classCell(cell)
+	case "Tendance":
+		return trendCell(cell)
+	}
+	return indicatorCell(p, colName, cell)
+}
+
+// indicatorCell ajoute le symbole du niveau de qualité aux valeurs E. coli et
+// Enté. ; les valeurs non numériques sont signalées par "?"
+func indicatorCell(p thresholdProfile, colName, cell string) string {
+	if colName != "E. coli" && colName != "Enté." {
+		return cell
+	}
+	m := parseMeasurement(cell)
+	switch {
+	case m.ok():
+		return withSymbol(m.String(), m.level(p, colName))
+	case m.invalid():
+		return cell + " ?"
+	}
+	return cell
+}
+
+// measureStyle renvoie le style d'une valeur E. coli ou Enté. selon son niveau ;
+// les valeurs inutilisables sont estompées
+func measureStyle(p thresholdProfile, colName, cell string) lipgloss.Style {
+	m := parseMeasurement(cell)
+	if !m.ok() {
+		return activeTheme.fg(activeTheme.Muted)
+	}
+	return activeTheme.level(m.level(p, colName))
+}
+
+// siteName supprime 'PLAGE DE ' au début du nom de site
+func siteName(site string) string {
+	plagePrefix := "PLAGE DE "
+	if strings.HasPrefix(strings.ToUpper(site), plagePrefix) {
+		return site[len(plagePrefix):]
+	}
+	return site
+}
+
+// capitalize met la première lettre en majuscule (pour 'Point de prélèvement')
+func capitalize(s string) string {
+	if len(s) > 0 {
+		return strings.ToUpper(s[:1]) + s[1:]
+	}
+	return s
+}
+
+// Colonnes du tableau des détails triées numériquement
+var numericColumns = map[string]bool{"E. coli": true, "Enté.": true}
+
+// sortRowsByColumn trie les lignes de données (hors en-tête) selon la colonne
+// nommée : numériquement pour E. coli et Enté. (voir Measurement pour les
+// valeurs censurées ; les cellules inutilisables restent en fin de tableau),
+// par ordre alphabétique sinon
+func sortRowsByColumn(rows [][]string, colName string, desc bool) {
+	if len(rows) < 2 {
+		return
+	}
+	colIdx := -1
+	for idx, col := range rows[0] {
+		if col == colName {
+			colIdx = idx
+			break
+		}
+	}
+	if colIdx == -1 {
+		return
+	}
+	cellAt := func(row []string) string {
+		if colIdx < len(row) {
+			return row[colIdx]
+		}
+		return ""
+	}
+	dataRows := rows[1:]
+	sort.SliceStable(dataRows, func(i, j int) bool {
+		a, b := cellAt(dataRows[i]), cellAt(dataRows[j])
+		cmp := strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		if numericColumns[colName] {
+			ma, mb := parseMeasurement(a), parseMeasurement(b)
+			if ma.ok() != mb.ok() {
+				return ma.ok()
+			}
+			cmp = compareMeasurements(ma, mb)
+		}
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
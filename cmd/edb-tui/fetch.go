@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const csvURL = "https://raw.githubusercontent.com/adriens/edb-noumea-data/main/data/resume.csv"
+const detailsURL = "https://raw.githubusercontent.com/adriens/edb-noumea-data/main/data/details.csv"
+
+// Délai maximal d'une requête vers GitHub
+const fetchTimeout = 30 * time.Second
+
+var httpClient = &http.Client{Timeout: fetchTimeout, Transport: fetchTransport()}
+
+// fetchTransport ajoute au transport HTTP par défaut les URL file://, pour les
+// jeux de données enregistrés sur disque
+func fetchTransport() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	return t
+}
+
+// dataMsg transporte les deux CSV récupérés, agrégés si plusieurs jeux de
+// données sont sélectionnés
+type dataMsg struct {
+	data      [][]string
+	details   [][]string
+	fetchedAt time.Time
+	dataset   string // sélection récupérée : nom d'un jeu de données ou allDatasets
+}
+
+// dataSource lance la récupération des données d'une sélection de jeux de
+// données : fetchAllData, ou le cache partagé des sessions SSH. force demande
+// des données fraîches (rafraîchissement manuel), même si un cache est récent.
+type dataSource func(id int, selection string, force bool, progress chan<- fetchProgressMsg) tea.Cmd
+
+// Charge les CSV des jeux de données de la sélection l'un après l'autre ;
+// sans cache, force est sans effet. L'avancement de chaque fichier est envoyé
+// sur progress sans bloquer, sauf la fin de chaque fichier, toujours
+// transmise ; le canal est fermé à la fin.
+func fetchAllData(id int, selection string, force bool, progress chan<- fetchProgressMsg) tea.Cmd {
+	selected := selectedDatasets(selection)
+	return func() tea.Msg {
+		defer close(progress)
+		resumes := make([][][]string, len(selected))
+		details := make([][][]string, len(selected))
+		for source, f := range datasetFiles(selected) {
+			report := func(received, total int64, done bool) {
+				msg := fetchProgressMsg{id: id, source: source, received: received, total: total, done: done}
+				if done {
+					// L'interface lit l'avancement jusqu'au résultat : la fin
+					// du fichier est attendue plutôt que perdue si le canal
+					// est plein
+					progress <- msg
+					return
+				}
+				select {
+				case progress <- msg:
+				default:
+				}
+			}
+			records, err := fetchCSVData(f.url, report)
+			if err != nil {
+				return err
+			}
+			if d := selected[f.dataset]; f.resume {
+				resumes[f.dataset] = d.normalize(records, resumeColumns)
+			} else {
+				details[f.dataset] = d.normalize(records, detailsColumns)
+			}
+		}
+		for i := range selected {
+			if resumes[i] == nil {
+				resumes[i] = resumeFromDetails(details[i])
+			}
+		}
+		return dataMsg{mergeTables(resumes, selected), mergeTables(details, selected), time.Now(), selection}
+	}
+}
+
+func fetchCSVData(url string, report func(received, total int64, done bool)) ([][]string, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, classifyFetchError(url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &fetchError{kind: fetchErrHTTP, url: url, status: resp.StatusCode, err: errors.New(resp.Status)}
+	}
+	body := &countingReader{r: resp.Body, total: resp.ContentLength, report: report}
+	// Séparateur détecté sur l'en-tête : virgule, ou point-virgule pour les
+	// CSV d'autres communes exportés depuis un tableur
+	buffered := bufio.NewReader(body)
+	head, _ := buffered.Peek(4096)
+	reader := csv.NewReader(buffered)
+	reader.Comma = csvComma(head)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, classifyFetchError(url, err)
+	}
+	report(body.received, resp.ContentLength, true)
+	if len(records) == 0 {
+		return nil, &fetchError{kind: fetchErrParse, url: url, err: errors.New(tr("fichier vide"))}
+	}
+	return records, nil
+}
+
+// csvComma renvoie le séparateur d'un CSV d'après sa première ligne : le
+// point-virgule s'il y est plus fréquent que la virgule
+func csvComma(head []byte) rune {
+	first, _, _ := bytes.Cut(head, []byte("\n"))
+	if bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+// fetchErrorKind est la catégorie d'un échec de récupération des CSV
+type fetchErrorKind int
+
+const (
+	fetchErrNetwork fetchErrorKind = iota // autre erreur réseau (connexion refusée, TLS...)
+	fetchErrDNS                           // nom d'hôte introuvable
+	fetchErrTimeout                       // pas de réponse dans le délai
+	fetchErrHTTP                          // réponse HTTP autre que 200
+	fetchErrParse                         // contenu qui n'est pas un CSV valide
+)
+
+// Nom de chaque catégorie dans le fichier de log
+var fetchErrorKindNames = []string{"network", "dns", "timeout", "http", "csv"}
+
+func (k fetchErrorKind) String() string { return fetchErrorKindNames[k] }
+
+// fetchError est un échec de récupération d'un CSV, rangé par catégorie pour
+// afficher un message et un conseil adaptés
+type fetchError struct {
+	kind   fetchErrorKind
+	url    string
+	status int // code HTTP pour fetchErrHTTP
+	err    error
+}
+
+// classifyFetchError range une erreur de requête ou de lecture du CSV
+func classifyFetchError(url string, err error) *fetchError {
+	e := &fetchError{kind: fetchErrNetwork, url: url, err: err}
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &dnsErr) && !dnsErr.IsTimeout:
+		e.kind = fetchErrDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		e.kind = fetchErrTimeout
+	case errors.As(err, &parseErr):
+		e.kind = fetchErrParse
+	}
+	return e
+}
+
+func (e *fetchError) Error() string {
+	return e.title() + tr(" : ") + e.detail()
+}
+
+func (e *fetchError) Unwrap() error { return e.err }
+
+// host renvoie le nom d'hôte de l'URL demandée
+func (e *fetchError) host() string {
+	if u, err := url.Parse(e.url); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return e.url
+}
+
+// file renvoie le nom du fichier demandé (resume.csv, details.csv)
+func (e *fetchError) file() string {
+	if u, err := url.Parse(e.url); err == nil && u.Path != "" {
+		return path.Base(u.Path)
+	}
+	return e.url
+}
+
+// title renvoie le libellé court de la catégorie
+func (e *fetchError) title() string {
+	switch e.kind {
+	case fetchErrDNS:
+		return tr("Erreur DNS")
+	case fetchErrTimeout:
+		return tr("Délai dépassé")
+	case fetchErrHTTP:
+		return tr("Erreur HTTP %d", e.status)
+	case fetchErrParse:
+		return tr("CSV illisible")
+	}
+	return tr("Erreur réseau")
+}
+
+// detail décrit l'échec en une phrase
+func (e *fetchError) detail() string {
+	switch e.kind {
+	case fetchErrDNS:
+		return tr("impossible de résoudre %s", e.host())
+	case fetchErrTimeout:
+		return tr("pas de réponse de %s en %s", e.host(), fetchTimeout)
+	case fetchErrHTTP:
+		return tr("%s a répondu %s pour %s", e.host(), e.err, e.file())
+	case fetchErrParse:
+		return tr("%s : %v", e.file(), e.err)
+	}
+	return tr("%s : %v", e.host(), e.err)
+}
+
+// hint conseille l'utilisateur selon la catégorie de l'échec
+func (e *fetchError) hint() string {
+	switch e.kind {
+	case fetchErrDNS:
+		return tr("Vérifiez la connexion réseau et la configuration DNS.")
+	case fetchErrTimeout:
+		return tr("Le réseau ou GitHub est lent ; le prochain essai peut aboutir.")
+	case fetchErrHTTP:
+		switch {
+		case e.status == http.StatusNotFound && e.url != csvURL && e.url != detailsURL:
+			return tr("Vérifiez l'adresse du fichier dans les jeux de données de la configuration.")
+		case e.status == http.StatusNotFound:
+			return tr("Le fichier a peut-être été déplacé dans le dépôt edb-noumea-data.")
+		case e.status == http.StatusForbidden || e.status == http.StatusTooManyRequests:
+			return tr("Limite de requêtes GitHub atteinte : patientez avant de réessayer.")
+		case e.status >= 500:
+			return tr("GitHub est indisponible pour le moment ; réessayez plus tard.")
+		}
+		return tr("Réponse inattendue de GitHub ; réessayez plus tard.")
+	case fetchErrParse:
+		return tr("Le fichier reçu n'est pas un CSV valide (portail captif, proxy ?).")
+	}
+	return tr("Vérifiez la connexion réseau (proxy, pare-feu).")
+}
+
+// errorMessage et errorHint renvoient le message et le conseil d'une erreur
+// quelconque, catégorisée ou non
+func errorMessage(err error) string {
+	var fe *fetchError
+	if errors.As(err, &fe) {
+		return fe.Error()
+	}
+	return tr("Erreur: %v", err)
+}
+
+func errorHint(err error) string {
+	var fe *fetchError
+	if errors.As(err, &fe) {
+		return fe.hint()
+	}
+	return ""
+}
+
+// errorKind renvoie la catégorie d'une erreur pour le fichier de log
+func errorKind(err error) string {
+	var fe *fetchError
+	if errors.As(err, &fe) {
+		return fe.kind.String()
+	}
+	return "autre"
+}
+
+// formatAge renvoie l'âge des données affichées, formaté de façon
+// lisible : "45 s", "12 min", "3 h 05", "2 j"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return tr("%d s", int(d/time.Second))
+	case d < time.Hour:
+		return tr("%d min", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return tr("%d h %02d", int(d/time.Hour), int(d/time.Minute)%60)
+	}
+	return tr("%d j", int(d/(24*time.Hour)))
+}
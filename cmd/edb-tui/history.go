@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// historyTab est l'onglet Historique : liste des points de prélèvement et
+// prélèvements successifs du point sélectionné, en tableau ou en graphique
+type historyTab struct {
+	width     int
+	height    int
+	points    []pointHistory
+	selected  int       // index du point sélectionné
+	offset    int       // premier point affiché dans la liste (défilement)
+	showChart bool      // graphique à la place du tableau des prélèvements
+	chart     timeChart // graphique du point sélectionné
+	overlay   int       // covariable superposée : index dans covariates plus un, 0 sans
+
+	thresholds thresholdProfile // profil de seuils choisi
+}
+
+func newHistoryTab() historyTab {
+	return historyTab{thresholds: defaultThresholds}
+}
+
+func (t historyTab) Init() tea.Cmd { return nil }
+
+func (t historyTab) title() string { return tr("Historique") }
+
+func (t historyTab) shortcuts() []shortcut {
+	shortcuts := []shortcut{
+		newShortcut("Point de prélèvement", keys.Up, keys.Down),
+		newShortcut("Graphique", keys.Chart),
+		newShortcut("Échelle log", keys.LogScale),
+	}
+	if len(covariates) > 0 {
+		shortcuts = append(shortcuts, newShortcut("Covariable", keys.Overlay))
+	}
+	return append(shortcuts, shortcut{bindings: []key.Binding{keys.Top, keys.Bottom}, desc: "Premier / dernier point", hidden: true})
+}
+
+func (t historyTab) Update(msg tea.Msg) (tabModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+	case dataMsg:
+		t.points = groupByPoint(parseSamples(msg.details))
+	case thresholdsMsg:
+		t.thresholds = msg.profile
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Up):
+			t.selected--
+		case key.Matches(msg, keys.Down):
+			t.selected++
+		case key.Matches(msg, keys.Top):
+			t.selected = 0
+		case key.Matches(msg, keys.Bottom):
+			t.selected = len(t.points) - 1
+		case key.Matches(msg, keys.Chart):
+			t.showChart = !t.showChart
+		case key.Matches(msg, keys.LogScale):
+			t.chart.logScale = !t.chart.logScale
+		case key.Matches(msg, keys.Overlay) && len(covariates) > 0:
+			t.overlay = (t.overlay + 1) % (len(covariates) + 1)
+		}
+	case tea.MouseMsg:
+		switch {
+		case msg.Button == tea.MouseButtonWheelUp:
+			t.selected -= wheelStep
+		case msg.Button == tea.MouseButtonWheelDown:
+			t.selected += wheelStep
+		case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft:
+			// Liste des points : bordure haute (1) puis une ligne par point
+			if msg.X < t.listWidth() && msg.Y >= 1 && msg.Y <= t.visibleRows() {
+				t.selected = t.offset + msg.Y - 1
+			}
+		}
+	}
+	if t.selected >= len(t.points) {
+		t.selected = len(t.points) - 1
+	}
+	if t.selected < 0 {
+		t.selected = 0
+	}
+	t.offset = windowStart(t.offset, t.selected+1, t.visibleRows(), len(t.points))
+	return t.syncChart(), nil
+}
+
+// syncChart prépare le graphique du point sélectionné à la taille du panneau
+// de droite, sous les lignes d'en-tête
+func (t historyTab) syncChart() historyTab {
+	if len(t.points) == 0 {
+		return t
+	}
+	logScale := t.chart.logScale
+	t.chart = newTimeChart(t.points[t.selected], t.thresholds)
+	t.chart.logScale = logScale
+	if c, ok := t.covariate(); ok {
+		from, to, _ := t.chart.bounds()
+		t.chart.overlay = &chartOverlay{name: c.name, unit: c.unit, points: c.overlay(from, to)}
+	}
+	t.chart = t.chart.Update(tea.WindowSizeMsg{Width: t.width - t.listWidth() - 4, Height: t.height - len(t.headlines())})
+	return t
+}
+
+// covariate renvoie la covariable superposée, s'il y en a une
+func (t historyTab) covariate() (covariate, bool) {
+	if t.overlay == 0 || t.overlay > len(covariates) {
+		return covariate{}, false
+	}
+	return covariates[t.overlay-1], true
+}
+
+// headlines renvoie les lignes d'en-tête du point sélectionné : nom,
+// classement, tendance, puis les corrélations avec la covariable superposée
+func (t historyTab) headlines() []string {
+	p := t.points[t.selected]
+	width := t.width - t.listWidth() - 6
+	lines := []string{
+		activeTheme.header().Render(truncate(p.site+" – "+p.point, width)),
+		truncate(classify(p).headline(), width),
+		truncate(trendOf(p).headline(), width),
+	}
+	if c, ok := t.covariate(); ok {
+		for _, line := range c.correlationLines(p) {
+			lines = append(lines, activeTheme.fg(activeTheme.Muted).Render(truncate(line, width)))
+		}
+	}
+	return lines
+}
+
+// visibleRows renvoie le nombre de points affichables dans la liste (bordure comprise)
+func (t historyTab) visibleRows() int {
+	return t.height - 2
+}
+
+// listWidth renvoie la largeur de la liste des points, bordure comprise
+func (t historyTab) listWidth() int {
+	return t.width * 2 / 5
+}
+
+func (t historyTab) View() string {
+	if len(t.points) == 0 {
+		return tr("Aucun prélèvement.")
+	}
+	rows := t.visibleRows()
+	listInner := t.listWidth() - 4
+	var list []string
+	for i := t.offset; i < len(t.points) && i < t.offset+rows; i++ {
+		p := t.points[i]
+		label := truncate(fmt.Sprintf("%s – %s (%d)", p.site, p.point, len(p.samples)), listInner)
+		style := lipgloss.NewStyle()
+		if i == t.selected {
+			style = activeTheme.selected().Bold(true)
+		}
+		list = append(list, style.Render(padRight(label, listInner)))
+	}
+	listBox := lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(activeTheme.border()).Padding(0, 1).Width(t.listWidth()).Render(strings.Join(list, "\n"))
+
+	p := t.points[t.selected]
+	lines := t.headlines()
+	headlines := len(lines)
+	if t.showChart {
+		lines = append(lines, t.chart.View())
+	} else {
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render(padRight(tr("Date"), 18)+"  "+padRight(tr("E. coli"), 10)+"  "+tr("Enté.")))
+		for i, s := range p.samples {
+			// Lignes d'en-tête et titres des colonnes au-dessus des prélèvements
+			if i >= t.height-headlines-1 {
+				break
+			}
+			lines = append(lines, padRight(s.date, 18)+"  "+
+				measureStyle(t.thresholds, "E. coli", s.ecoli).Render(padRight(indicatorCell(t.thresholds, "E. coli", s.ecoli), 10))+"  "+
+				measureStyle(t.thresholds, "Enté.", s.ente).Render(indicatorCell(t.thresholds, "Enté.", s.ente)))
+		}
+	}
+	samplesBox := lipgloss.NewStyle().Padding(0, 2).MaxWidth(t.width - t.listWidth()).Render(strings.Join(lines, "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, listBox, samplesBox)
+}
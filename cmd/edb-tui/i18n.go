@@ -0,0 +1,405 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// locale regroupe les traductions et les formats de date d'une langue. Les
+// textes de l'interface sont écrits en français dans le code et servent de
+// clé de traduction : une clé absente du catalogue reste en français.
+type locale struct {
+	name     string
+	messages map[string]string
+	dateTime string // date et heure : barre de log, fichiers exportés
+	date     string // date seule : axes des graphiques
+	longDate string // date et heure dans une phrase ("le ... à ...")
+	clock    string // heure seule : entrées du log
+}
+
+var locales = map[string]locale{
+	"fr": {
+		name:     "fr",
+		dateTime: "02/01/2006 15:04:05",
+		date:     "02/01/2006",
+		longDate: "02/01/2006 à 15:04:05",
+		clock:    "15:04:05",
+	},
+	"en": {
+		name:     "en",
+		messages: enMessages,
+		dateTime: "Jan 2, 2006 3:04:05 PM",
+		date:     "Jan 2, 2006",
+		longDate: "Jan 2, 2006 at 3:04:05 PM",
+		clock:    "3:04:05 PM",
+	},
+}
+
+// activeLocale est la langue de l'interface
+var activeLocale = locales["fr"]
+
+// tr traduit un texte dans la langue de l'interface, puis le formate avec
+// fmt.Sprintf si des arguments sont donnés
+func tr(msg string, args ...any) string {
+	if t, ok := activeLocale.messages[msg]; ok {
+		msg = t
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// formatDateTime, formatDate, formatLongDate et formatClock formatent une date
+// selon la langue de l'interface
+func formatDateTime(t time.Time) string { return t.Format(activeLocale.dateTime) }
+func formatDate(t time.Time) string     { return t.Format(activeLocale.date) }
+func formatLongDate(t time.Time) string { return t.Format(activeLocale.longDate) }
+func formatClock(t time.Time) string    { return t.Format(activeLocale.clock) }
+
+// envLang choisit la langue selon LC_ALL, LC_MESSAGES ou LANG (par exemple
+// en_US.UTF-8) ; le français par défaut ou si la langue n'est pas gérée
+func envLang() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		c := os.Getenv(v)
+		if c == "" || c == "C" || c == "POSIX" {
+			continue
+		}
+		lang := strings.ToLower(c)
+		if i := strings.IndexAny(lang, "_.-@"); i >= 0 {
+			lang = lang[:i]
+		}
+		if _, ok := locales[lang]; ok {
+			return lang
+		}
+		return "fr"
+	}
+	return "fr"
+}
+
+// setLocale active la langue donnée ; une langue inconnue est une erreur
+func setLocale(lang string) error {
+	l, ok := locales[lang]
+	if !ok {
+		return fmt.Errorf("langue %q non gérée (fr, en)", lang)
+	}
+	activeLocale = l
+	return nil
+}
+
+// Catalogue anglais
+var enMessages = map[string]string{
+	// Ponctuation : espace avant les deux-points en français seulement
+	" : ": ": ",
+
+	// Habillage
+	"edb-noumea-tui : le premier tui Glamour en Go pour consulter la qualité des eaux de baignade à %s": "edb-noumea-tui: the first Glamour TUI in Go to check bathing water quality in %s",
+	"Eaux de baignade - %s":                         "Bathing water - %s",
+	"Données non encore récupérées.":                "Data not fetched yet.",
+	"Données récupérées le %s (source : %s)":        "Data fetched on %s (source: %s)",
+	"Dernier refresh : %s | Prochain : %s":          "Last refresh: %s | Next: %s",
+	"auto en pause":                                 "auto-refresh paused",
+	"dans %s":                                       "in %s",
+	"Données périmées depuis %s (récupérées le %s)": "Data stale for %s (fetched on %s)",
+	"%d s":                         "%d s",
+	"%d min":                       "%d min",
+	"%d h %02d":                    "%d h %02d",
+	"%d j":                         "%d d",
+	"Récupération : %s · %.1f s":   "Fetching: %s · %.1f s",
+	"%d o":                         "%d B",
+	"%.1f Ko":                      "%.1f KB",
+	"%.1f Mo":                      "%.1f MB",
+	"Chargement des données...":    "Loading data...",
+	"Erreur: %v":                   "Error: %v",
+	"Erreur de configuration : %v": "Configuration error: %v",
+	"Terminal trop petit\n\n%d×%d (minimum %d×%d)\n\nAgrandissez la fenêtre\nou appuyez sur q pour quitter.": "Terminal too small\n\n%d×%d (minimum %d×%d)\n\nEnlarge the window\nor press q to quit.",
+
+	// Popups
+	"Appuyez sur une touche pour fermer.":               "Press any key to close.",
+	"Appuyez sur n'importe quelle touche pour revenir.": "Press any key to go back.",
+	"Développé par Adrien S.":                           "Developed by Adrien S.",
+	"Scannez le QR code pour accéder au projet :":       "Scan the QR code to open the project:",
+	"[QR code non disponible]":                          "[QR code unavailable]",
+	"Général":                                           "General",
+	"Onglet %s":                                         "%s tab",
+	"Nombre de bactéries Escherichia coli pour 100ml d'eau (NPP = Nombre le Plus Probable)": "Escherichia coli bacteria per 100ml of water (MPN = Most Probable Number)",
+	"Nombre d'entérocoques pour 100ml d'eau (NPP = Nombre le Plus Probable)":                "Enterococci per 100ml of water (MPN = Most Probable Number)",
+	"Seuils (%s) :":      "Thresholds (%s):",
+	"excellent":          "excellent",
+	"passable":           "fair",
+	"baignade interdite": "no swimming",
+
+	// Erreurs de récupération
+	"fichier vide":               "empty file",
+	"Erreur DNS":                 "DNS error",
+	"Délai dépassé":              "Timeout",
+	"Erreur HTTP %d":             "HTTP error %d",
+	"CSV illisible":              "Unreadable CSV",
+	"Erreur réseau":              "Network error",
+	"impossible de résoudre %s":  "cannot resolve %s",
+	"pas de réponse de %s en %s": "no response from %s within %s",
+	"%s a répondu %s pour %s":    "%s answered %s for %s",
+	"%s : %v":                    "%s: %v",
+	"Vérifiez la connexion réseau et la configuration DNS.":                       "Check your network connection and DNS settings.",
+	"Le réseau ou GitHub est lent ; le prochain essai peut aboutir.":              "The network or GitHub is slow; the next attempt may succeed.",
+	"Le fichier a peut-être été déplacé dans le dépôt edb-noumea-data.":           "The file may have moved in the edb-noumea-data repository.",
+	"Vérifiez l'adresse du fichier dans les jeux de données de la configuration.": "Check the file address in the configured datasets.",
+	"Limite de requêtes GitHub atteinte : patientez avant de réessayer.":          "GitHub rate limit reached: wait before retrying.",
+	"GitHub est indisponible pour le moment ; réessayez plus tard.":               "GitHub is unavailable right now; try again later.",
+	"Réponse inattendue de GitHub ; réessayez plus tard.":                         "Unexpected response from GitHub; try again later.",
+	"Le fichier reçu n'est pas un CSV valide (portail captif, proxy ?).":          "The downloaded file is not valid CSV (captive portal, proxy?).",
+	"Vérifiez la connexion réseau (proxy, pare-feu).":                             "Check your network connection (proxy, firewall).",
+	"nouvel essai en cours…":                                                      "retrying…",
+	"nouvel essai dans %s":                                                        "retry in %s",
+	"Nouvel essai demandé":                                                        "Retry requested",
+	"Nouvel essai automatique après %d échec(s)":                                  "Automatic retry after %d failure(s)",
+
+	// Presse-papiers
+	"texte":    "text",
+	"Markdown": "Markdown",
+	"CSV":      "CSV",
+	"État":     "Status",
+	"%d prélèvement(s) copié(s) dans le presse-papiers (%s)": "%d sample(s) copied to the clipboard (%s)",
+	"Format de copie : %s":  "Copy format: %s",
+	"Copie impossible : %v": "Copy failed: %v",
+
+	// QR code des points
+	"Position (geo:)":                                   "Position (geo:)",
+	"Résumé du dernier prélèvement":                     "Latest sample summary",
+	"Données source":                                    "Source data",
+	"QR code non enregistré : %v":                       "QR code not saved: %v",
+	"QR code enregistré dans %s":                        "QR code saved to %s",
+	"Agrandissez le terminal pour afficher le QR code.": "Enlarge the terminal to display the QR code.",
+	"Autre touche : fermer":                             "Any other key: close",
+
+	// Statistiques
+	"plage sélectionnée (%s)":               "selected beach (%s)",
+	"tous les points, toutes les dates":     "all points, all dates",
+	"filtre de l'onglet Détails":            "Details tab filter",
+	"échelle linéaire":                      "linear scale",
+	"échelle logarithmique":                 "log scale",
+	"Périmètre : %s · %d prélèvements · %s": "Scope: %s · %d samples · %s",
+	" · période : %s":                       " · period: %s",
+	"Prélèvements":                          "Samples",
+	"Minimum":                               "Minimum",
+	"Médiane":                               "Median",
+	"Moyenne":                               "Mean",
+	"Moyenne géométrique":                   "Geometric mean",
+	"Maximum":                               "Maximum",
+	"min %.0f":                              "min %.0f",
+	"méd. %.0f":                             "med. %.0f",
+	"moy. %.1f":                             "mean %.1f",
+	"géo. %.1f":                             "geo. %.1f",
+	"max %.0f":                              "max %.0f",
+
+	// Classement 2006/7/CE
+	"Classement":   "Classification",
+	"excellente":   "excellent",
+	"bonne":        "good",
+	"suffisante":   "sufficient",
+	"insuffisante": "poor",
+	"non classé":   "not classified",
+	"Classement des points (eaux côtières, percentiles log-normaux sur 4 ans) :": "Point classification (coastal waters, log-normal percentiles over 4 years):",
+	"P95 E. coli ≤ 250 et Enté. ≤ 100 : %s":                                      "P95 E. coli ≤ 250 and Ent. ≤ 100: %s",
+	"P95 E. coli ≤ 500 et Enté. ≤ 200 : %s":                                      "P95 E. coli ≤ 500 and Ent. ≤ 200: %s",
+	"P90 E. coli ≤ 500 et Enté. ≤ 185 : %s":                                      "P90 E. coli ≤ 500 and Ent. ≤ 185: %s",
+	"Classement 2006/7/CE : %s":                                                  "2006/7/EC classification: %s",
+	"Au moins deux prélèvements de chaque indicateur sont nécessaires.":          "At least two samples of each indicator are required.",
+	"%d prélèvements sur %d ans, percentiles log-normaux :":                      "%d samples over %d years, log-normal percentiles:",
+	"%s : P95 %.0f, P90 %.0f":                                                    "%s: P95 %.0f, P90 %.0f",
+	"Tous les critères de qualité excellente sont respectés.":                    "All excellent quality criteria are met.",
+	"Qualité %s manquée : P%d %s %.0f > %.0f":                                    "%s quality missed: P%d %s %.0f > %.0f",
+	"Prélèvements déterminants :":                                                "Decisive samples:",
+	"Indicatif : la directive exige %d prélèvements.":                            "Indicative: the directive requires %d samples.",
+
+	// Valeurs censurées
+	"au-delà de la limite de dénombrement":                                        "above the counting limit",
+	"sous la limite de quantification":                                            "below the quantification limit",
+	"%s (valeur non numérique)":                                                   "%s (non-numeric value)",
+	" · %d valeur(s) non numérique(s) ignorée(s)":                                 " · %d non-numeric value(s) ignored",
+	"%d valeur(s) E. coli ou Enté. non numérique(s), ignorée(s) dans les calculs": "%d non-numeric E. coli or Ent. value(s), ignored in calculations",
+
+	// Tendances
+	"Tendance":                            "Trend",
+	"stable":                              "stable",
+	"hausse":                              "rising",
+	"baisse":                              "falling",
+	"dégradation":                         "worsening",
+	"Tendance : moins de %d prélèvements": "Trend: fewer than %d samples",
+	"Tendance : %s %s":                    "Trend: %s %s",
+	"Mann-Kendall sur les %d derniers prélèvements :": "Mann-Kendall over the last %d samples:",
+	"%s : Z %+.2f, Theil-Sen ×%s sur la période":      "%s: Z %+.2f, Theil-Sen ×%s over the period",
+	"Dégradation significative : %s":                  "Significant worsening: %s",
+
+	// Graphiques
+	"Graphique": "Chart",
+	"seuils":    "thresholds",
+	"Agrandissez le terminal pour afficher le graphique.": "Enlarge the terminal to display the chart.",
+	"Aucun prélèvement daté.":                             "No dated sample.",
+	"Précisez la plage avec --beach":                      "Specify the beach with --beach",
+	"Aucune plage ne correspond à %q":                     "No beach matches %q",
+	"Aucun prélèvement de %q sur la période (%s)":         "No sample of %q in the period (%s)",
+
+	// Comparaison
+	"Marquer la plage":                "Mark beach",
+	"Comparer les plages marquées":    "Compare marked beaches",
+	"Marquer / comparer":              "Mark / compare",
+	"Comparaison des plages marquées": "Marked beaches comparison",
+	"%d derniers prélèvements, sparklines en échelle logarithmique commune": "Last %d samples, sparklines on a shared logarithmic scale",
+	"Marquez au moins deux plages avec %s pour les comparer":                "Mark at least two beaches with %s to compare them",
+	"Dernier prélèvement": "Latest sample",
+	"Moy. géo. E. coli":   "E. coli geo. mean",
+	"Moy. géo. Enté.":     "Ent. geo. mean",
+	"Max E. coli":         "E. coli max",
+	"Max Enté.":           "Ent. max",
+	"Dépassements":        "Exceedances",
+	"%d %% (%d/%d)":       "%d%% (%d/%d)",
+	"Évolution E. coli":   "E. coli trend",
+	"Évolution Enté.":     "Ent. trend",
+
+	// Période
+	"Période":                    "Period",
+	"Période suivante":           "Next period",
+	"Période personnalisée":      "Custom period",
+	"Période : %s":               "Period: %s",
+	"%d derniers jours":          "last %d days",
+	"saison %d-%d":               "%d-%d season",
+	"saison %d":                  "%d season",
+	"jusqu'au %s":                "until %s",
+	"depuis le %s":               "since %s",
+	"du %s au %s":                "%s to %s",
+	"toutes les dates":           "all dates",
+	"date illisible : %q":        "unreadable date: %q",
+	"indiquez au moins une date": "enter at least one date",
+	"la date de fin précède la date de début": "the end date is before the start date",
+	"AAAA-MM-JJ": "YYYY-MM-DD",
+	"Du : ":      "From: ",
+	"Au : ":      "To: ",
+	"Date vide : période ouverte de ce côté":                   "Empty date: the period is open on that side",
+	"Tab : champ suivant  Entrée : appliquer  Échap : annuler": "Tab: next field  Enter: apply  Esc: cancel",
+	"Aucun prélèvement sur la période (%s).":                   "No sample in the period (%s).",
+
+	// Profils de seuils
+	"Seuils":                                "Thresholds",
+	"Profil de seuils":                      "Threshold profile",
+	"Seuils : %s":                           "Thresholds: %s",
+	"Profil local":                          "Local profile",
+	"Directive 2006/7/CE, eaux intérieures": "Directive 2006/7/EC, inland waters",
+	"Directive 2006/7/CE, eaux côtières":    "Directive 2006/7/EC, coastal waters",
+	"Valeurs guides OMS (2003)":             "WHO guideline values (2003)",
+	"Seuil dépassé au dernier prélèvement (%s) : %s": "Threshold exceeded at the latest sample (%s): %s",
+
+	// Covariables
+	"Covariable": "Covariate",
+	"max %s":     "max %s",
+	"%.0f h":     "%.0f h",
+	"%.0f j":     "%.0f d",
+	"cumul":      "total",
+	"moyenne":    "mean",
+	"%s (%s avant le prélèvement, corrélation de Spearman) :": "%s (%s before sampling, Spearman correlation):",
+	"%s : ρ %s (n=%d)":            "%s: ρ %s (n=%d)",
+	"Covariable non chargée : %v": "Covariate not loaded: %v",
+
+	// Jeux de données
+	"Commune":          "Municipality",
+	"Commune suivante": "Next municipality",
+	"Commune : %s":     "Municipality: %s",
+
+	// Serveur SSH
+	"Serveur SSH en écoute sur %s": "SSH server listening on %s",
+	"Session SSH ouverte (%s)":     "SSH session opened (%s)",
+
+	// Log
+	"Application quittée": "Application closed",
+	"Rafraîchissement manuel demandé. Dernier : %s. Prochain : %s.":        "Manual refresh requested. Last: %s. Next: %s.",
+	"Rafraîchissement automatique déclenché. Dernier : %s. Prochain : %s.": "Automatic refresh triggered. Last: %s. Next: %s.",
+	"Journal non enregistré sur disque : %v":                               "Log not saved to disk: %v",
+	"ERREUR":                                "ERROR",
+	"AVERT.":                                "WARN",
+	"INFO":                                  "INFO",
+	"Rafraîchissement automatique en pause": "Automatic refresh paused",
+	"Rafraîchissement automatique repris. Prochain : %s.": "Automatic refresh resumed. Next: %s.",
+	"Données rafraîchies (%s)":                            "Data refreshed (%s)",
+
+	// Onglets
+	"Résumé":       "Summary",
+	"Détails":      "Details",
+	"Statistiques": "Statistics",
+	"Historique":   "History",
+	"Journal":      "Log",
+	"Carte":        "Map",
+
+	// Raccourcis
+	"Quitter":                    "Quit",
+	"Rafraîchir":                 "Refresh",
+	"Pause auto":                 "Pause auto-refresh",
+	"Réessayer":                  "Retry",
+	"Réessayer après une erreur": "Retry after an error",
+	"Masquer":                    "Dismiss",
+	"Masquer l'erreur":           "Dismiss error",
+	"Copier la ligne":            "Copy row",
+	"Copier le tableau":          "Copy table",
+	"Copier ligne / tableau":     "Copy row / table",
+	"Format de copie":            "Copy format",
+	"QR code du point":           "Point QR code",
+	"Contenu suivant":            "Next content",
+	"Périmètre":                  "Scope",
+	"Échelle log":                "Log scale",
+	"À propos":                   "About",
+	"Légende":                    "Legend",
+	"Aide":                       "Help",
+	"Onglets":                    "Tabs",
+	"Onglet suivant":             "Next tab",
+	"Onglet précédent":           "Previous tab",
+	"Aller à l'onglet":           "Go to tab",
+	"Haut":                       "Up",
+	"Bas":                        "Down",
+	"Gauche":                     "Left",
+	"Droite":                     "Right",
+	"Page précédente":            "Previous page",
+	"Page suivante":              "Next page",
+	"Début":                      "Top",
+	"Fin":                        "Bottom",
+	"Début / fin":                "Top / bottom",
+	"Défiler":                    "Scroll",
+	"Suivre":                     "Follow",
+	"Trier E. coli":              "Sort E. coli",
+	"Trier Enté.":                "Sort Ent.",
+	"Sélection détail":           "Select row",
+	"Première / dernière ligne":  "First / last row",
+	"Point de prélèvement":       "Sampling point",
+	"Premier / dernier point":    "First / last point",
+	"Point le plus proche":       "Nearest point",
+	"Échap":                      "Esc",
+	"Entrée":                     "Enter",
+	"Espace":                     "Space",
+
+	// Tableaux
+	"Plage":                       "Beach",
+	"Status":                      "Status",
+	"Site":                        "Site",
+	"Date":                        "Date",
+	"E. coli":                     "E. coli",
+	"Enté.":                       "Ent.",
+	"Escherichia coli":            "Escherichia coli",
+	"Entérocoques":                "Enterococci",
+	"Baignade autorisée":          "Swimming allowed",
+	"Baignade déconseillée":       "Swimming not advised",
+	"Baignade interdite":          "Swimming prohibited",
+	"Aucun prélèvement.":          "No samples.",
+	"Journal vide.":               "Log is empty.",
+	"Filtre : %s (%d/%d entrées)": "Filter: %s (%d/%d entries)",
+	"Tous les niveaux":            "All levels",
+	"Avertissements et erreurs":   "Warnings and errors",
+	"Erreurs seulement":           "Errors only",
+	"Filtrer par niveau":          "Filter by level",
+	"Filtrer":                     "Filter",
+	"Histogramme E. coli :":       "E. coli histogram:",
+	"Histogramme Enté. :":         "Ent. histogram:",
+	"%d points sur la carte":      "%d points on the map",
+	", %d sans position connue":   ", %d without a known position",
+	"Aucun point de prélèvement localisé.": "No sampling point with a known position.",
+	"%s — %s : E. coli %s, Enté. %s (%s)":  "%s — %s: E. coli %s, Ent. %s (%s)",
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Niveaux minimaux proposés par le filtre du journal, dans l'ordre du cycle
+var journalFilters = []slog.Level{slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// Nombre maximal d'entrées gardées par l'onglet Journal : les plus anciennes
+// sont oubliées, pour qu'une session ouverte longtemps ne grossisse pas sans
+// fin (le fichier de log garde tout)
+const journalMaxEntries = 1000
+
+// journalTab est l'onglet Journal : les dernières actions de la session, les
+// plus récentes en bas, filtrables par niveau
+type journalTab struct {
+	width    int
+	height   int
+	entries  []logEntry
+	minLevel slog.Level // niveau minimal des entrées affichées
+	offset   int        // première entrée affichée (défilement)
+	follow   bool       // suit automatiquement les nouvelles entrées
+}
+
+func newJournalTab() journalTab {
+	return journalTab{follow: true, minLevel: slog.LevelInfo}
+}
+
+func (t journalTab) Init() tea.Cmd { return nil }
+
+func (t journalTab) title() string { return tr("Journal") }
+
+func (t journalTab) shortcuts() []shortcut {
+	return []shortcut{
+		newShortcut("Défiler", keys.Up, keys.Down, keys.PageUp, keys.PageDown),
+		{bindings: []key.Binding{keys.Top}, desc: "Début", hidden: true},
+		newShortcut("Suivre", keys.Bottom),
+		newShortcut("Filtrer par niveau", keys.Filter),
+	}
+}
+
+func (t journalTab) Update(msg tea.Msg) (tabModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+	case logEntryMsg:
+		t.entries = append(t.entries, logEntry(msg))
+		if len(t.entries) > journalMaxEntries {
+			// Le défilement (compté en entrées filtrées) reste sur les mêmes
+			// entrées
+			if t.entries[0].level >= t.minLevel {
+				t.offset = max(t.offset-1, 0)
+			}
+			t.entries = t.entries[1:]
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Up):
+			t.offset--
+			t.follow = false
+		case key.Matches(msg, keys.Down):
+			t.offset++
+		case key.Matches(msg, keys.PageUp):
+			t.offset -= t.height
+			t.follow = false
+		case key.Matches(msg, keys.PageDown):
+			t.offset += t.height
+		case key.Matches(msg, keys.Top):
+			t.offset = 0
+			t.follow = false
+		case key.Matches(msg, keys.Bottom):
+			t.follow = true
+		case key.Matches(msg, keys.Filter):
+			for i, level := range journalFilters {
+				if level == t.minLevel {
+					t.minLevel = journalFilters[(i+1)%len(journalFilters)]
+					break
+				}
+			}
+			t.follow = true
+		}
+	case tea.MouseMsg:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			t.offset -= wheelStep
+			t.follow = false
+		case tea.MouseButtonWheelDown:
+			t.offset += wheelStep
+		}
+	}
+	total, rows := len(t.visibleEntries()), t.visibleRows()
+	last := total - rows
+	if t.follow || t.offset >= last {
+		t.offset = last
+		t.follow = true
+	}
+	t.offset = clampOffset(t.offset, rows, total)
+	return t, nil
+}
+
+// visibleRows renvoie le nombre d'entrées affichables sous la ligne du filtre
+func (t journalTab) visibleRows() int {
+	return t.height - 1
+}
+
+// visibleEntries renvoie les entrées d'un niveau au moins égal au filtre
+func (t journalTab) visibleEntries() []logEntry {
+	var entries []logEntry
+	for _, e := range t.entries {
+		if e.level >= t.minLevel {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func (t journalTab) View() string {
+	entries := t.visibleEntries()
+	filter := tr("Tous les niveaux")
+	switch t.minLevel {
+	case slog.LevelWarn:
+		filter = tr("Avertissements et erreurs")
+	case slog.LevelError:
+		filter = tr("Erreurs seulement")
+	}
+	status := lipgloss.NewStyle().Faint(true).Render(truncate(tr("Filtre : %s (%d/%d entrées)", filter, len(entries), len(t.entries)), t.width))
+	if len(entries) == 0 {
+		return status + "\n" + tr("Journal vide.")
+	}
+	end := t.offset + t.visibleRows()
+	if end > len(entries) {
+		end = len(entries)
+	}
+	lines := []string{status}
+	for _, entry := range entries[t.offset:end] {
+		lines = append(lines, entry.style().Render(truncate(entry.String(), t.width)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap regroupe les raccourcis clavier de l'application. Les touches par
+// défaut peuvent être remplacées action par action dans la configuration.
+type keyMap struct {
+	Quit      key.Binding
+	Refresh   key.Binding
+	Pause     key.Binding
+	Retry     key.Binding
+	Dismiss   key.Binding
+	About     key.Binding
+	Legend    key.Binding
+	Help      key.Binding
+	NextTab   key.Binding
+	PrevTab   key.Binding
+	Tabs      key.Binding // une touche par onglet, dans l'ordre de la barre
+	Stats     key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	Left      key.Binding
+	Right     key.Binding
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Top       key.Binding
+	Bottom    key.Binding
+	SortEcoli key.Binding
+	SortEnte  key.Binding
+	Filter    key.Binding
+	Copy      key.Binding
+	CopyAll   key.Binding
+	CopyFmt   key.Binding
+	QR        key.Binding
+	Scope     key.Binding
+	LogScale  key.Binding
+	Chart     key.Binding
+	Mark      key.Binding
+	Compare   key.Binding
+	Period    key.Binding
+	PeriodSet key.Binding
+	Threshold key.Binding
+	Overlay   key.Binding
+	Dataset   key.Binding
+}
+
+// keys est la keymap utilisée par le modèle racine et les onglets
+var keys = defaultKeyMap()
+
+// Noms d'affichage des touches spéciales
+var keyNames = map[string]string{
+	"up": "↑", "down": "↓", "left": "←", "right": "→",
+	"pgup": "PgUp", "pgdown": "PgDn", "home": "Home", "end": "End",
+	"tab": "Tab", "shift+tab": "Shift+Tab", "ctrl+c": "Ctrl+C", "esc": "Échap",
+	"enter": "Entrée", " ": "Espace",
+}
+
+// keyName renvoie le nom d'affichage d'une touche
+func keyName(k string) string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	if strings.HasPrefix(k, "ctrl+") {
+		return "Ctrl+" + strings.ToUpper(k[5:])
+	}
+	return k
+}
+
+// newBinding crée un raccourci dont l'aide liste toutes ses touches
+func newBinding(desc string, keys ...string) key.Binding {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = tr(keyName(k))
+	}
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(names, "/"), desc))
+}
+
+func defaultKeyMap() keyMap {
+	k := keyMap{
+		Quit:      newBinding("Quitter", "q", "ctrl+c"),
+		Refresh:   newBinding("Rafraîchir", "r"),
+		Pause:     newBinding("Pause auto", "p"),
+		Retry:     newBinding("Réessayer", "R"),
+		Dismiss:   newBinding("Masquer l'erreur", "x"),
+		About:     newBinding("À propos", "a"),
+		Legend:    newBinding("Légende", "l"),
+		Help:      newBinding("Aide", "?"),
+		NextTab:   newBinding("Onglet suivant", "tab"),
+		PrevTab:   newBinding("Onglet précédent", "shift+tab"),
+		Tabs:      newBinding("Aller à l'onglet", "1", "2", "3", "4", "5", "6"),
+		Stats:     newBinding("Statistiques", "s"),
+		Up:        newBinding("Haut", "up", "k"),
+		Down:      newBinding("Bas", "down", "j"),
+		Left:      newBinding("Gauche", "left"),
+		Right:     newBinding("Droite", "right"),
+		PageUp:    newBinding("Page précédente", "pgup", "ctrl+b"),
+		PageDown:  newBinding("Page suivante", "pgdown", "ctrl+f"),
+		Top:       newBinding("Début", "home", "g"),
+		Bottom:    newBinding("Fin", "end", "G"),
+		SortEcoli: newBinding("Trier E. coli", "e"),
+		SortEnte:  newBinding("Trier Enté.", "n"),
+		Filter:    newBinding("Filtrer", "f"),
+		Copy:      newBinding("Copier la ligne", "y"),
+		CopyAll:   newBinding("Copier le tableau", "Y"),
+		CopyFmt:   newBinding("Format de copie", "c"),
+		QR:        newBinding("QR code du point", "Q"),
+		Scope:     newBinding("Périmètre", "v"),
+		LogScale:  newBinding("Échelle log", "L"),
+		Chart:     newBinding("Graphique", "t"),
+		Mark:      newBinding("Marquer la plage", "m"),
+		Compare:   newBinding("Comparer les plages marquées", "C"),
+		Period:    newBinding("Période suivante", "d"),
+		PeriodSet: newBinding("Période personnalisée", "D"),
+		Threshold: newBinding("Profil de seuils", "T"),
+		Overlay:   newBinding("Covariable", "o"),
+		Dataset:   newBinding("Commune suivante", "M"),
+	}
+	k.Tabs.SetHelp("1-6", k.Tabs.Help().Desc)
+	return k
+}
+
+// named associe à chaque action son nom dans le fichier de configuration
+func (k *keyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit": &k.Quit, "refresh": &k.Refresh, "pause": &k.Pause, "retry": &k.Retry,
+		"dismiss": &k.Dismiss, "about": &k.About,
+		"legend": &k.Legend, "help": &k.Help, "next_tab": &k.NextTab,
+		"prev_tab": &k.PrevTab, "tabs": &k.Tabs, "stats": &k.Stats,
+		"up": &k.Up, "down": &k.Down, "left": &k.Left, "right": &k.Right,
+		"page_up": &k.PageUp, "page_down": &k.PageDown, "top": &k.Top,
+		"bottom": &k.Bottom, "sort_ecoli": &k.SortEcoli, "sort_ente": &k.SortEnte,
+		"filter": &k.Filter, "copy": &k.Copy, "copy_all": &k.CopyAll,
+		"copy_format": &k.CopyFmt, "qr": &k.QR,
+		"scope": &k.Scope, "log_scale": &k.LogScale, "chart": &k.Chart,
+		"mark": &k.Mark, "compare": &k.Compare,
+		"period": &k.Period, "custom_period": &k.PeriodSet,
+		"thresholds": &k.Threshold, "overlay": &k.Overlay,
+		"dataset": &k.Dataset,
+	}
+}
+
+// newKeyMap applique les touches de la configuration aux touches par défaut.
+// Une action sans touche est désactivée.
+func newKeyMap(overrides map[string][]string) (keyMap, error) {
+	k := defaultKeyMap()
+	named := k.named()
+	// Parcours dans un ordre stable pour que les erreurs soient reproductibles
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b, ok := named[name]
+		if !ok {
+			return k, fmt.Errorf("action inconnue dans la configuration des touches : %q", name)
+		}
+		*b = newBinding(b.Help().Desc, overrides[name]...)
+		if len(overrides[name]) == 0 {
+			b.SetEnabled(false)
+		}
+	}
+	return k, nil
+}
+
+// shortcut est une entrée de l'aide : un ou plusieurs raccourcis regroupés
+// sous une même description (en français, traduite au rendu), par exemple
+// ↑/↓ pour défiler
+type shortcut struct {
+	bindings []key.Binding
+	desc     string
+	hidden   bool // seulement dans l'aide complète, pas dans le pied de page
+}
+
+func newShortcut(desc string, bindings ...key.Binding) shortcut {
+	return shortcut{bindings: bindings, desc: desc}
+}
+
+// enabled renvoie les raccourcis actifs de l'entrée
+func (s shortcut) enabled() []key.Binding {
+	var bs []key.Binding
+	for _, b := range s.bindings {
+		if b.Enabled() {
+			bs = append(bs, b)
+		}
+	}
+	return bs
+}
+
+// short renvoie l'entrée du pied de page, avec la première touche de chaque
+// raccourci : "[↑/↓] Défiler"
+func (s shortcut) short() string {
+	var names []string
+	for _, b := range s.enabled() {
+		first, _, _ := strings.Cut(b.Help().Key, "/")
+		names = append(names, first)
+	}
+	return "[" + strings.Join(names, "/") + "] " + tr(s.desc)
+}
+
+// full renvoie toutes les touches de l'entrée, pour l'aide complète
+func (s shortcut) full() string {
+	var names []string
+	for _, b := range s.enabled() {
+		names = append(names, b.Help().Key)
+	}
+	return strings.Join(names, ", ")
+}
+
+// globalShortcuts liste les raccourcis disponibles dans tous les onglets
+func globalShortcuts() []shortcut {
+	shortcuts := []shortcut{
+		newShortcut("Quitter", keys.Quit),
+		newShortcut("Rafraîchir", keys.Refresh),
+		newShortcut("Pause auto", keys.Pause),
+		newShortcut("À propos", keys.About),
+		newShortcut("Légende", keys.Legend),
+		newShortcut("Aide", keys.Help),
+		newShortcut("Onglets", keys.NextTab, keys.Tabs),
+		newShortcut("Période", keys.Period, keys.PeriodSet),
+		newShortcut("Seuils", keys.Threshold),
+		{bindings: []key.Binding{keys.PrevTab}, desc: "Onglet précédent", hidden: true},
+		{bindings: []key.Binding{keys.Stats}, desc: "Statistiques", hidden: true},
+		{bindings: []key.Binding{keys.Retry}, desc: "Réessayer après une erreur", hidden: true},
+		{bindings: []key.Binding{keys.Dismiss}, desc: "Masquer l'erreur", hidden: true},
+	}
+	if len(datasets) > 1 {
+		shortcuts = append(shortcuts, newShortcut("Commune", keys.Dataset))
+	}
+	return shortcuts
+}
+
+// shortHelp renvoie les entrées du pied de page
+func shortHelp(shortcuts []shortcut) []string {
+	var hints []string
+	for _, s := range shortcuts {
+		if !s.hidden && len(s.enabled()) > 0 {
+			hints = append(hints, s.short())
+		}
+	}
+	return hints
+}
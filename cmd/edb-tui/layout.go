@@ -0,0 +1,180 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Points de rupture (en colonnes de terminal) du moteur de mise en page
+const (
+	minWidth       = 60  // en dessous : écran "terminal trop petit"
+	minHeight      = 20  // idem pour la hauteur
+	tinyWidth      = 80  // masque aussi la colonne Date
+	narrowWidth    = 110 // masque la colonne Point de prélèvement
+	wideWidth      = 160 // la box de détail passe à côté du tableau
+	minCellWidth   = 6   // largeur minimale d'une cellule tronquée
+	minBoxWidth    = 40  // largeur minimale de la box de détail
+	minVisibleRows = 3   // nombre minimal de lignes de détails affichées
+	compactHeight  = 30  // en dessous : intro masquée, une seule ligne de log
+	chromeWidth    = 8   // bordure, padding et marge de la box principale
+)
+
+// Colonnes masquées en priorité quand la place manque (ordre de priorité)
+var lowPriorityColumns = []struct {
+	name     string
+	maxWidth int // colonne masquée en dessous de cette largeur
+}{
+	{"Point de prélèvement", narrowWidth},
+	{"Commune", narrowWidth},
+	{"Tendance", tinyWidth},
+	{"Classement", tinyWidth},
+	{"Date", tinyWidth},
+}
+
+// layout décrit la mise en page calculée pour une taille de terminal donnée
+type layout struct {
+	width         int             // largeur du terminal
+	height        int             // hauteur du terminal
+	contentWidth  int             // largeur utile à l'intérieur de la box principale
+	tooSmall      bool            // terminal sous la taille minimale
+	sideBySide    bool            // box de détail à droite du tableau des détails
+	hiddenColumns map[string]bool // colonnes du tableau des détails masquées
+	showIntro     bool            // affiche la phrase de présentation
+	logLines      int             // nombre d'entrées affichées dans la zone de log
+}
+
+// computeLayout applique les points de rupture à la taille du terminal
+func computeLayout(width, height int) layout {
+	l := layout{width: width, height: height, hiddenColumns: map[string]bool{}}
+	l.tooSmall = width < minWidth || height < minHeight
+	// Box principale : bordure double (2) + padding horizontal (2*2) + marge extérieure (2)
+	l.contentWidth = width - chromeWidth
+	if l.contentWidth < 0 {
+		l.contentWidth = 0
+	}
+	for _, col := range lowPriorityColumns {
+		if width < col.maxWidth {
+			l.hiddenColumns[col.name] = true
+		}
+	}
+	l.sideBySide = width >= wideWidth
+	l.showIntro = height >= compactHeight
+	l.logLines = 3
+	if height < compactHeight {
+		l.logLines = 1
+	}
+	return l
+}
+
+// contentLayout renvoie la mise en page vue depuis un onglet, qui ne connaît
+// que la taille de sa zone de contenu
+func contentLayout(width, height int) layout {
+	return computeLayout(width+chromeWidth, height)
+}
+
+// logHeight renvoie la hauteur de la zone de log : bordure (2), ligne de
+// refresh (1) et entrées du log
+func (l layout) logHeight() int {
+	return 2 + 1 + l.logLines
+}
+
+// headerHeight renvoie la hauteur de l'en-tête : intro éventuelle, titre et
+// date de récupération des données
+func (l layout) headerHeight() int {
+	if l.showIntro {
+		return 3
+	}
+	return 2
+}
+
+// popupWidth renvoie la largeur d'une popup : la moitié de l'écran,
+// mais au moins min colonnes tant que le terminal le permet
+func (l layout) popupWidth(min int) int {
+	w := l.width / 2
+	if w < min {
+		w = min
+	}
+	if w > l.width-4 {
+		w = l.width - 4
+	}
+	return w
+}
+
+// truncate coupe une chaîne à la largeur donnée en terminant par une ellipse
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	return runewidth.Truncate(s, width, "…")
+}
+
+// padRight complète une chaîne avec des espaces jusqu'à la largeur donnée
+func padRight(s string, width int) string {
+	pad := width - runewidth.StringWidth(s)
+	if pad < 0 {
+		pad = 0
+	}
+	return s + strings.Repeat(" ", pad)
+}
+
+// fitColumns réduit les colonnes les plus larges, une colonne à la fois,
+// jusqu'à ce que le tableau (overhead compris) tienne dans la largeur disponible
+func fitColumns(widths []int, avail, overhead int) []int {
+	fitted := append([]int(nil), widths...)
+	total := overhead
+	for _, w := range fitted {
+		total += w
+	}
+	for total > avail {
+		widest := 0
+		for i, w := range fitted {
+			if w > fitted[widest] {
+				widest = i
+			}
+		}
+		if fitted[widest] <= minCellWidth {
+			break
+		}
+		fitted[widest]--
+		total--
+	}
+	return fitted
+}
+
+// tableOverhead renvoie la largeur ajoutée par le padding des cellules (1+1)
+// et les séparateurs " │ " pour un tableau de n colonnes
+func tableOverhead(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return 2*n + 3*(n-1)
+}
+
+// wrapHints répartit les raccourcis du pied de page sur plusieurs lignes
+// sans jamais couper un raccourci en deux
+func wrapHints(hints []string, width int) string {
+	var lines []string
+	line := ""
+	for _, h := range hints {
+		switch {
+		case line == "":
+			line = h
+		case runewidth.StringWidth(line)+2+runewidth.StringWidth(h) <= width:
+			line += "  " + h
+		default:
+			lines = append(lines, line)
+			line = h
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	for i := range lines {
+		lines[i] = truncate(lines[i], width)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Rotation du fichier de log : taille maximale et nombre d'anciens fichiers
+// conservés (edb-tui.log.1, edb-tui.log.2, ...)
+const (
+	logMaxSize = 1 << 20
+	logBackups = 3
+)
+
+// logger écrit le journal dans le fichier de log ; il n'écrit nulle part tant
+// que openLogFile n'a pas été appelé
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// logEntry est une entrée du journal de la session
+type logEntry struct {
+	time  time.Time
+	level slog.Level
+	msg   string
+}
+
+// Libellés courts des niveaux, alignés dans le journal
+func levelLabel(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return tr("ERREUR")
+	case level >= slog.LevelWarn:
+		return tr("AVERT.")
+	}
+	return tr("INFO")
+}
+
+// String renvoie l'entrée telle qu'affichée dans la zone de log
+func (e logEntry) String() string {
+	return fmt.Sprintf("[%s] %-6s %s", formatClock(e.time), levelLabel(e.level), e.msg)
+}
+
+// style renvoie le style d'une entrée selon son niveau
+func (e logEntry) style() lipgloss.Style {
+	switch {
+	case e.level >= slog.LevelError:
+		return activeTheme.level(2)
+	case e.level >= slog.LevelWarn:
+		return activeTheme.level(1)
+	}
+	return lipgloss.NewStyle()
+}
+
+// stateDir renvoie le dossier des données d'état ($XDG_STATE_HOME, par
+// défaut ~/.local/state)
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state"), nil
+}
+
+// openLogFile ouvre le fichier de log de l'application et y branche logger
+func openLogFile() (*rotatingFile, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return nil, err
+	}
+	dir = filepath.Join(dir, "edb-tui")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := openRotatingFile(filepath.Join(dir, "edb-tui.log"), logMaxSize, logBackups)
+	if err != nil {
+		return nil, err
+	}
+	logger = slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	return f, nil
+}
+
+// rotatingFile est un fichier en ajout renommé en .1 (les anciens décalés en
+// .2, .3, ...) dès qu'il dépasserait maxSize octets
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+func openRotatingFile(path string, maxSize int64, backups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate décale les anciens fichiers et repart d'un fichier vide
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// addLog ajoute une entrée au journal : elle est écrite dans le fichier de
+// log avec ses attributs, conservée pour la zone de log (dernières entrées)
+// et transmise à l'onglet Journal
+func (m Model) addLog(level slog.Level, msg string, args ...any) Model {
+	entry := logEntry{time: m.clock(), level: level, msg: msg}
+	logger.Log(context.Background(), level, msg, args...)
+	logs := append(m.logs, entry)
+	if len(logs) > 3 {
+		logs = logs[len(logs)-3:]
+	}
+	m.logs = logs
+	m.tabs[tabJournal], _ = m.tabs[tabJournal].Update(logEntryMsg(entry))
+	return m
+}
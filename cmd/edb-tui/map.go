@@ -0,0 +1,444 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// Positions (approximatives) des points de prélèvement du jeu intégré, une
+// ligne par point, repérés par id_point_prelevement ou à défaut par le nom
+// exact de la plage
+//
+//go:embed data/points.csv
+var pointsCSV string
+
+// Trait de côte simplifié de la presqu'île de Nouméa
+//
+//go:embed data/coastline.csv
+var coastlineCSV string
+
+// Largeur minimale de l'onglet pour afficher la box de détail à côté de la carte
+const mapSideBySideWidth = 100
+
+// geoPoint est une position en degrés décimaux
+type geoPoint struct {
+	lat, lon float64
+}
+
+// pointLocation est une ligne d'une table des positions
+type pointLocation struct {
+	id   string
+	site string
+	pos  geoPoint
+}
+
+var (
+	// Positions des points par jeu de données : celles du jeu intégré, puis
+	// celles des fichiers "points" de la configuration
+	pointLocations = map[string][]pointLocation{noumeaDataset.Name: parsePointLocations(readEmbeddedCSV(pointsCSV))}
+	coastline      = loadCoastline()
+)
+
+// readEmbeddedCSV lit un CSV embarqué et renvoie ses lignes sans l'en-tête
+func readEmbeddedCSV(s string) [][]string {
+	records, err := csv.NewReader(strings.NewReader(s)).ReadAll()
+	if err != nil || len(records) < 2 {
+		return nil
+	}
+	return records[1:]
+}
+
+// parseGeoPoint lit une latitude et une longitude
+func parseGeoPoint(lat, lon string) (geoPoint, bool) {
+	la, err1 := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	lo, err2 := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	return geoPoint{la, lo}, err1 == nil && err2 == nil
+}
+
+// parsePointLocations lit les lignes d'une table des positions : identifiant,
+// plage, latitude et longitude
+func parsePointLocations(records [][]string) []pointLocation {
+	var locations []pointLocation
+	for _, rec := range records {
+		if len(rec) < 4 {
+			continue
+		}
+		if pos, ok := parseGeoPoint(rec[2], rec[3]); ok {
+			locations = append(locations, pointLocation{id: strings.TrimSpace(rec[0]), site: rec[1], pos: pos})
+		}
+	}
+	return locations
+}
+
+// loadPointLocations lit le fichier des positions d'un jeu de données, au
+// format de data/points.csv ; le séparateur (virgule ou point-virgule) est
+// détecté sur l'en-tête
+func loadPointLocations(path string) ([]pointLocation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(strings.NewReader(string(b)))
+	r.Comma = csvComma(b)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var locations []pointLocation
+	if len(records) > 1 {
+		locations = parsePointLocations(records[1:])
+	}
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("%s : aucune position lisible", path)
+	}
+	return locations, nil
+}
+
+func loadCoastline() []geoPoint {
+	var line []geoPoint
+	for _, rec := range readEmbeddedCSV(coastlineCSV) {
+		if len(rec) < 2 {
+			continue
+		}
+		if pos, ok := parseGeoPoint(rec[0], rec[1]); ok {
+			line = append(line, pos)
+		}
+	}
+	return line
+}
+
+// normalizeName simplifie un nom de plage pour la comparaison (casse, accents)
+func normalizeName(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.NewReplacer("é", "e", "è", "e", "ê", "e", "î", "i", "ô", "o", "â", "a", "à", "a", "ç", "c", "’", "'").Replace(s)
+}
+
+// Préfixes retirés des noms de plage avant comparaison, dans cet ordre :
+// "PLAGE DE LA BAIE DES CITRONS" et "Baie des Citrons" désignent la même plage
+var sitePrefixes = []string{"plage des ", "plage du ", "plage de ", "les ", "le ", "la ", "l'"}
+
+// siteKey renvoie la clé de comparaison d'un nom de plage : normalisé, sans
+// le préfixe "Plage de" ni l'article
+func siteKey(s string) string {
+	s = normalizeName(s)
+	for _, prefix := range sitePrefixes {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	return s
+}
+
+// locate renvoie la position d'un point de prélèvement du jeu de données d,
+// parmi les seules positions de ce jeu : par identifiant exact (sans le
+// préfixe du jeu ajouté à l'agrégation), sinon par nom de plage exact (casse,
+// accents et préfixe ignorés)
+func locate(p pointHistory, d dataset) (geoPoint, bool) {
+	locations := pointLocations[d.Name]
+	if len(p.samples) > 0 && p.samples[0].pointID != "" {
+		id := strings.TrimPrefix(p.samples[0].pointID, d.Name+"/")
+		for _, loc := range locations {
+			if loc.id == id {
+				return loc.pos, true
+			}
+		}
+	}
+	site := siteKey(p.site)
+	if site == "" {
+		return geoPoint{}, false
+	}
+	for _, loc := range locations {
+		if siteKey(loc.site) == site {
+			return loc.pos, true
+		}
+	}
+	return geoPoint{}, false
+}
+
+// projection convertit des positions en points du canvas, à la même échelle
+// en latitude et en longitude (le nord en haut)
+type projection struct {
+	minLat, minLon   float64
+	cosLat, scale    float64
+	offsetX, offsetY float64
+	dotH             int
+}
+
+func newProjection(points []geoPoint, dotW, dotH int) projection {
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		minLat, maxLat = math.Min(minLat, p.lat), math.Max(maxLat, p.lat)
+		minLon, maxLon = math.Min(minLon, p.lon), math.Max(maxLon, p.lon)
+	}
+	// Marge de 5 % autour de la zone affichée
+	padLat, padLon := (maxLat-minLat)*0.05, (maxLon-minLon)*0.05
+	minLat, maxLat, minLon, maxLon = minLat-padLat, maxLat+padLat, minLon-padLon, maxLon+padLon
+	// Un degré de longitude est plus court qu'un degré de latitude
+	cosLat := math.Cos((minLat + maxLat) / 2 * math.Pi / 180)
+	spanX, spanY := (maxLon-minLon)*cosLat, maxLat-minLat
+	scale := math.Min(float64(dotW-1)/spanX, float64(dotH-1)/spanY)
+	return projection{
+		minLat: minLat, minLon: minLon, cosLat: cosLat, scale: scale,
+		offsetX: (float64(dotW-1) - spanX*scale) / 2,
+		offsetY: (float64(dotH-1) - spanY*scale) / 2,
+		dotH:    dotH,
+	}
+}
+
+// dot renvoie la position d'un point sur le canvas
+func (p projection) dot(g geoPoint) (int, int) {
+	x := p.offsetX + (g.lon-p.minLon)*p.cosLat*p.scale
+	y := p.offsetY + (g.lat-p.minLat)*p.scale
+	return int(math.Round(x)), p.dotH - 1 - int(math.Round(y))
+}
+
+// mapMarker est un point de prélèvement placé sur la carte
+type mapMarker struct {
+	point  pointHistory
+	cx, cy int // cellule du marqueur
+}
+
+// sampleLevel renvoie le niveau de qualité d'un prélèvement selon les seuils
+// du profil p : le pire des niveaux E. coli et Enté. utilisables
+func sampleLevel(p thresholdProfile, s sample) int {
+	level := 0
+	for _, colName := range []string{"E. coli", "Enté."} {
+		if m := s.measure(colName); m.ok() {
+			level = max(level, m.level(p, colName))
+		}
+	}
+	return level
+}
+
+// mapTab est l'onglet Carte : les points de prélèvement localisés placés sur
+// un trait de côte en braille, colorés selon leur dernier prélèvement
+type mapTab struct {
+	width     int
+	height    int
+	details   [][]string
+	selection string         // sélection affichée : nom d'un jeu ou allDatasets
+	points    []pointHistory // points localisés
+	missing   int            // points sans position connue
+	selected  int            // index du point sélectionné dans points
+
+	thresholds thresholdProfile // profil de seuils choisi
+}
+
+func newMapTab() mapTab {
+	return mapTab{thresholds: defaultThresholds}
+}
+
+func (t mapTab) Init() tea.Cmd { return nil }
+
+func (t mapTab) title() string { return tr("Carte") }
+
+func (t mapTab) shortcuts() []shortcut {
+	return []shortcut{
+		newShortcut("Point le plus proche", keys.Left, keys.Up, keys.Down, keys.Right),
+		newShortcut("QR code du point", keys.QR),
+	}
+}
+
+func (t mapTab) Update(msg tea.Msg) (tabModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+	case dataMsg:
+		t.details, t.selection = msg.details, msg.dataset
+		t.points, t.missing = nil, 0
+		for _, p := range groupByPoint(parseSamples(msg.details)) {
+			if _, ok := locate(p, p.dataset(t.selection)); ok {
+				t.points = append(t.points, p)
+			} else {
+				t.missing++
+			}
+		}
+		if t.selected >= len(t.points) {
+			t.selected = 0
+		}
+	case thresholdsMsg:
+		t.thresholds = msg.profile
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Left):
+			t.selected = t.nearest("left")
+		case key.Matches(msg, keys.Right):
+			t.selected = t.nearest("right")
+		case key.Matches(msg, keys.Up):
+			t.selected = t.nearest("up")
+		case key.Matches(msg, keys.Down):
+			t.selected = t.nearest("down")
+		case key.Matches(msg, keys.QR):
+			if t.selected < len(t.points) {
+				p := t.points[t.selected]
+				return t, func() tea.Msg { return qrMsg{p} }
+			}
+		}
+	case tea.MouseMsg:
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			t.selected = t.markerAt(msg.X, msg.Y)
+		}
+	}
+	return t, nil
+}
+
+// mapSize renvoie la taille de la carte en cellules et la largeur de la box
+// de détail (0 si elle n'est pas affichée)
+func (t mapTab) mapSize() (int, int, int) {
+	boxWidth := 0
+	if t.width >= mapSideBySideWidth {
+		boxWidth = minBoxWidth + 2
+	}
+	w := t.width
+	if boxWidth > 0 {
+		w -= boxWidth + 2
+	}
+	// Dernière ligne : résumé sous la carte
+	return w, t.height - 1, boxWidth
+}
+
+// markers place les points localisés sur une carte de la taille donnée
+func (t mapTab) markers(c *brailleCanvas) (projection, []mapMarker) {
+	bounds := append([]geoPoint(nil), coastline...)
+	for _, p := range t.points {
+		pos, _ := locate(p, p.dataset(t.selection))
+		bounds = append(bounds, pos)
+	}
+	proj := newProjection(bounds, c.dotWidth(), c.dotHeight())
+	var markers []mapMarker
+	occupied := map[[2]int]bool{}
+	for _, p := range t.points {
+		pos, _ := locate(p, p.dataset(t.selection))
+		x, y := proj.dot(pos)
+		cx, cy := freeCell(occupied, x/2, y/4, c.width, c.height)
+		occupied[[2]int{cx, cy}] = true
+		markers = append(markers, mapMarker{point: p, cx: cx, cy: cy})
+	}
+	return proj, markers
+}
+
+// freeCell renvoie la cellule libre la plus proche de (cx, cy) : les points
+// d'une même plage, ou trop proches à cette échelle, ont chacun leur
+// marqueur, visible et accessible au clavier
+func freeCell(occupied map[[2]int]bool, cx, cy, width, height int) (int, int) {
+	if !occupied[[2]int{cx, cy}] {
+		return cx, cy
+	}
+	for r := 1; r < max(width, height); r++ {
+		// Décalages horizontaux d'abord : une cellule est plus haute que large
+		for _, d := range [][2]int{{r, 0}, {-r, 0}, {0, r}, {0, -r}, {r, r}, {-r, r}, {r, -r}, {-r, -r}} {
+			x, y := cx+d[0], cy+d[1]
+			if x >= 0 && x < width && y >= 0 && y < height && !occupied[[2]int{x, y}] {
+				return x, y
+			}
+		}
+	}
+	return cx, cy
+}
+
+// nearest renvoie le point le plus proche dans la direction demandée, ou le
+// point sélectionné s'il n'y en a aucun
+func (t mapTab) nearest(dir string) int {
+	w, h, _ := t.mapSize()
+	_, markers := t.markers(newBrailleCanvas(w, h))
+	if t.selected >= len(markers) {
+		return t.selected
+	}
+	from := markers[t.selected]
+	best, bestDist := t.selected, math.MaxInt
+	for i, mk := range markers {
+		// Une cellule est environ deux fois plus haute que large
+		dx, dy := mk.cx-from.cx, (mk.cy-from.cy)*2
+		var along, across int
+		switch dir {
+		case "left":
+			along, across = -dx, dy
+		case "right":
+			along, across = dx, dy
+		case "up":
+			along, across = -dy, dx
+		case "down":
+			along, across = dy, dx
+		}
+		if i == t.selected || along < 0 || (along == 0 && across == 0) || abs(across) > along*2+2 {
+			continue
+		}
+		if dist := along + 2*abs(across); dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// markerAt renvoie le point le plus proche d'un clic sur la carte, ou le point
+// sélectionné si le clic est trop loin de tout marqueur
+func (t mapTab) markerAt(x, y int) int {
+	w, h, _ := t.mapSize()
+	if x >= w || y >= h {
+		return t.selected
+	}
+	_, markers := t.markers(newBrailleCanvas(w, h))
+	best, bestDist := t.selected, 3
+	for i, mk := range markers {
+		if dist := abs(mk.cx-x) + abs(mk.cy-y); dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+func (t mapTab) View() string {
+	if len(t.points) == 0 {
+		return tr("Aucun point de prélèvement localisé.")
+	}
+	w, h, boxWidth := t.mapSize()
+	c := newBrailleCanvas(w, h)
+	proj, markers := t.markers(c)
+	for i := 1; i < len(coastline); i++ {
+		x0, y0 := proj.dot(coastline[i-1])
+		x1, y1 := proj.dot(coastline[i])
+		c.line(x0, y0, x1, y1, activeTheme.Border)
+	}
+	for i, mk := range markers {
+		if i == t.selected {
+			continue
+		}
+		level := sampleLevel(t.thresholds, mk.point.samples[0])
+		c.glyph(mk.cx, mk.cy, levelSymbols[level], activeTheme.level(level))
+	}
+	// Le point sélectionné est dessiné en dernier, en surbrillance, avec son nom
+	sel := markers[t.selected]
+	c.glyph(sel.cx, sel.cy, levelSymbols[sampleLevel(t.thresholds, sel.point.samples[0])], activeTheme.selected().Bold(true))
+	label := truncate(sel.point.site, w/2)
+	labelX := sel.cx + 2
+	if labelX+runewidth.StringWidth(label) > w {
+		labelX = sel.cx - 1 - runewidth.StringWidth(label)
+	}
+	c.text(labelX, sel.cy, label, activeTheme.header())
+
+	latest := sel.point.samples[0]
+	status := tr("%d points sur la carte", len(markers))
+	if t.missing > 0 {
+		status += tr(", %d sans position connue", t.missing)
+	}
+	if boxWidth == 0 {
+		status = tr("%s — %s : E. coli %s, Enté. %s (%s)", sel.point.site, sel.point.point, indicatorCell(t.thresholds, "E. coli", latest.ecoli), indicatorCell(t.thresholds, "Enté.", latest.ente), latest.date)
+	}
+	mapView := c.String() + "\n" + truncate(status, w)
+	if boxWidth == 0 {
+		return mapView
+	}
+	filtered := detailRows(t.details)
+	box := renderDetailBox(t.thresholds, filtered[0], filtered[latest.row], boxWidth, nil)
+	return lipgloss.JoinHorizontal(lipgloss.Top, mapView, "  ", box)
+}
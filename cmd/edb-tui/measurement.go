@@ -0,0 +1,177 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Qualificatif d'une mesure de laboratoire. Les dénombrements NPP sont bornés :
+// sous la limite de quantification le laboratoire rend "<15", au-delà de la
+// dilution la plus forte ">2420".
+const (
+	measureExact   = iota // valeur numérique
+	measureBelow          // "<L" : valeur inférieure à L
+	measureAbove          // ">L" : valeur supérieure à L
+	measureMissing        // cellule vide
+	measureInvalid        // cellule non numérique ("NA", "n.d.", ...)
+)
+
+// Measurement est un résultat d'analyse E. coli ou Enté. tel que rendu par le
+// laboratoire, avec son éventuel qualificatif de censure.
+//
+// Règles appliquées aux valeurs censurées :
+//   - affichage : la valeur est rendue telle quelle ("<15", ">2420") ;
+//   - calculs (statistiques, histogrammes, classement) : la limite est
+//     substituée à la valeur, "<15" compte pour 15 et ">2420" pour 2420 ;
+//   - couleur : "<L" prend le niveau de L (borne haute de la valeur réelle),
+//     ">L" celui d'une valeur juste au-dessus de L ;
+//   - tri : à limite égale, "<L" < L < ">L" ; les cellules vides ou non
+//     numériques sont toujours en fin de tableau ;
+//   - les cellules non numériques sont signalées par "?" et exclues des calculs.
+type Measurement struct {
+	qualifier int
+	value     float64 // valeur, ou limite si la mesure est censurée
+	raw       string  // texte de la cellule
+}
+
+// Préfixes reconnus pour les valeurs censurées
+var censorPrefixes = []struct {
+	prefix    string
+	qualifier int
+}{
+	{"<=", measureBelow},
+	{"≤", measureBelow},
+	{"<", measureBelow},
+	{">=", measureAbove},
+	{"≥", measureAbove},
+	{">", measureAbove},
+}
+
+// parseMeasurement interprète une cellule E. coli ou Enté. ; la virgule
+// décimale est acceptée
+func parseMeasurement(raw string) Measurement {
+	m := Measurement{raw: strings.TrimSpace(raw)}
+	s := m.raw
+	if s == "" {
+		m.qualifier = measureMissing
+		return m
+	}
+	for _, c := range censorPrefixes {
+		if rest, ok := strings.CutPrefix(s, c.prefix); ok {
+			m.qualifier, s = c.qualifier, strings.TrimSpace(rest)
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		m.qualifier = measureInvalid
+		return m
+	}
+	m.value = v
+	return m
+}
+
+// ok indique si la mesure est utilisable dans les calculs (exacte ou censurée)
+func (m Measurement) ok() bool {
+	return m.qualifier <= measureAbove
+}
+
+// censored indique si la mesure est une borne ("<L" ou ">L")
+func (m Measurement) censored() bool {
+	return m.qualifier == measureBelow || m.qualifier == measureAbove
+}
+
+// invalid indique si la cellule n'est ni vide ni numérique
+func (m Measurement) invalid() bool {
+	return m.qualifier == measureInvalid
+}
+
+// number renvoie la valeur utilisée dans les calculs (la limite pour une
+// mesure censurée)
+func (m Measurement) number() float64 {
+	return m.value
+}
+
+// level renvoie le niveau de qualité de la mesure, qui doit être utilisable,
+// selon les seuils du profil p
+func (m Measurement) level(p thresholdProfile, colName string) int {
+	n := int(math.Ceil(m.value))
+	if m.qualifier == measureAbove {
+		n++
+	}
+	return p.level(colName, n)
+}
+
+// String renvoie la mesure telle qu'affichée : la valeur du laboratoire avec
+// son qualificatif, "<15" ou ">2420"
+func (m Measurement) String() string {
+	switch m.qualifier {
+	case measureBelow:
+		return "<" + strconv.FormatFloat(m.value, 'f', -1, 64)
+	case measureAbove:
+		return ">" + strconv.FormatFloat(m.value, 'f', -1, 64)
+	}
+	return m.raw
+}
+
+// display renvoie la mesure affichée, ou "–" pour une cellule vide
+func (m Measurement) display() string {
+	if m.qualifier == measureMissing {
+		return "–"
+	}
+	return m.String()
+}
+
+// censorNote explique le qualificatif d'une mesure censurée
+func (m Measurement) censorNote() string {
+	if m.qualifier == measureAbove {
+		return tr("au-delà de la limite de dénombrement")
+	}
+	return tr("sous la limite de quantification")
+}
+
+// compareMeasurements ordonne deux mesures pour le tri croissant ; les
+// cellules inutilisables sont placées après les autres
+func compareMeasurements(a, b Measurement) int {
+	if a.ok() != b.ok() {
+		if a.ok() {
+			return -1
+		}
+		return 1
+	}
+	if c := cmp.Compare(a.value, b.value); c != 0 {
+		return c
+	}
+	return cmp.Compare(censorRank(a), censorRank(b))
+}
+
+// censorRank départage deux mesures de même valeur : "<L" avant L avant ">L"
+func censorRank(m Measurement) int {
+	switch m.qualifier {
+	case measureBelow:
+		return -1
+	case measureAbove:
+		return 1
+	}
+	return 0
+}
+
+// measure renvoie la mesure d'un indicateur d'un prélèvement
+func (s sample) measure(colName string) Measurement {
+	return parseMeasurement(sampleValue(s, colName))
+}
+
+// invalidMeasurements compte les cellules E. coli et Enté. non numériques
+func invalidMeasurements(samples []sample) int {
+	n := 0
+	for _, s := range samples {
+		for _, colName := range []string{"E. coli", "Enté."} {
+			if s.measure(colName).invalid() {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+// Les valeurs censurées gardent leur limite et leur qualificatif, la virgule
+// décimale est acceptée
+func TestParseMeasurement(t *testing.T) {
+	tests := []struct {
+		raw       string
+		qualifier int
+		value     float64
+		display   string
+	}{
+		{"<10", measureBelow, 10, "<10"},
+		{">2420", measureAbove, 2420, ">2420"},
+		{"≤ 15", measureBelow, 15, "<15"},
+		{">= 2419,6", measureAbove, 2419.6, ">2419.6"},
+		{"12,5", measureExact, 12.5, "12,5"},
+		{" 30 ", measureExact, 30, "30"},
+		{"", measureMissing, 0, "–"},
+		{"NA", measureInvalid, 0, "NA"},
+		{"-3", measureInvalid, 0, "-3"},
+	}
+	for _, tt := range tests {
+		m := parseMeasurement(tt.raw)
+		if m.qualifier != tt.qualifier || m.number() != tt.value {
+			t.Errorf("parseMeasurement(%q) = qualificatif %d, valeur %v, attendu %d, %v", tt.raw, m.qualifier, m.number(), tt.qualifier, tt.value)
+		}
+		if got := m.display(); got != tt.display {
+			t.Errorf("parseMeasurement(%q).display() = %q, attendu %q", tt.raw, got, tt.display)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Nombre de lignes parcourues par cran de molette
+const wheelStep = 3
+
+// tableHits décrit la géométrie du tableau des détails tel qu'il a été rendu
+type tableHits struct {
+	width   int         // largeur totale, bordures comprises
+	start   int         // nombre de lignes de données masquées au-dessus (défilement)
+	visible int         // nombre de lignes de données affichées
+	columns []hitColumn // colonnes affichées et leur plage horizontale
+}
+
+// hitColumn associe une colonne du tableau des détails à sa plage [x0, x1)
+type hitColumn struct {
+	name   string
+	x0, x1 int
+}
+
+// handleMouse traite les événements souris de l'habillage (popups, barre
+// d'onglets) et transmet les autres à l'onglet affiché, en coordonnées
+// relatives à sa zone de contenu
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	l := computeLayout(m.width, m.height)
+	if l.tooSmall {
+		return m, nil
+	}
+	leftClick := msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+
+	// Popup ouverte : un clic à l'extérieur la ferme, le reste est ignoré
+	if popup := m.renderPopup(l); popup != "" {
+		if leftClick {
+			w, h := lipgloss.Size(popup)
+			x0, y0 := placeOrigin(m.width, w), placeOrigin(m.height, h)
+			if msg.X < x0 || msg.X >= x0+w || msg.Y < y0 || msg.Y >= y0+h {
+				m.showAbout = false
+				m.showLegendPopup = false
+				m.showHelp = false
+				m.showQR = false
+				m.showCompare = false
+				m.showPeriodForm = false
+			}
+		}
+		return m, nil
+	}
+	if len(m.data) == 0 {
+		return m, nil
+	}
+
+	// Box principale : bordure (1) + padding (2 en largeur, 1 en hauteur),
+	// puis l'en-tête (bandeau d'erreur compris), la barre d'onglets et une ligne vide
+	barY := 2 + m.headerHeight(l)
+	if msg.Y == barY {
+		if leftClick {
+			bar, ranges := m.tabBar(l)
+			x := msg.X - 3 - placeOrigin(l.contentWidth, lipgloss.Width(bar))
+			for i, r := range ranges {
+				if x >= r[0] && x < r[1] {
+					return m.switchTab(i), nil
+				}
+			}
+		}
+		return m, nil
+	}
+	msg.X -= 3
+	msg.Y -= barY + 2
+	if msg.X < 0 || msg.X >= l.contentWidth || msg.Y < 0 || msg.Y >= m.tabHeight(l, m.tabs[m.activeTab]) {
+		return m, nil
+	}
+	return m.updateActiveTab(msg)
+}
+
+// handleMouse traite les événements souris de l'onglet Détails : sélection
+// d'une ligne, défilement et tri par clic sur l'en-tête
+func (t detailsTab) handleMouse(msg tea.MouseMsg) detailsTab {
+	section, hits := t.renderDetailsSection(contentLayout(t.width, t.height), t.height)
+	if hits.visible == 0 {
+		return t
+	}
+	total := len(t.shown) - 1
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp && msg.Action == tea.MouseActionPress:
+		return t.scroll(hits.start-wheelStep, hits.visible, total)
+	case msg.Button == tea.MouseButtonWheelDown && msg.Action == tea.MouseActionPress:
+		return t.scroll(hits.start+wheelStep, hits.visible, total)
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft:
+		// Coordonnées relatives au tableau, placé à gauche de la section
+		// centrée : ligne 0 = bordure haute, ligne 1 = en-tête, puis les
+		// lignes de données affichées
+		x := msg.X - placeOrigin(t.width, lipgloss.Width(section))
+		y := msg.Y
+		if x < 0 || x >= hits.width {
+			return t
+		}
+		switch {
+		case y == 1:
+			for _, col := range hits.columns {
+				if x >= col.x0 && x < col.x1 {
+					return t.toggleSort(col.name)
+				}
+			}
+		case y >= 2 && y < 2+hits.visible:
+			t.selected = hits.start + 1 + (y - 2)
+			t.offset = hits.start
+		}
+	}
+	return t
+}
+
+// scroll fait défiler la fenêtre du tableau des détails et garde la ligne
+// sélectionnée à l'intérieur de la fenêtre
+func (t detailsTab) scroll(start, visible, total int) detailsTab {
+	start = clampOffset(start, visible, total)
+	t.offset = start
+	if t.selected <= start {
+		t.selected = start + 1
+	}
+	if t.selected > start+visible {
+		t.selected = start + visible
+	}
+	return t
+}
+
+// scrollToSelection mémorise la fenêtre de défilement qui garde la ligne
+// sélectionnée visible, pour que la fenêtre ne saute pas au prochain rendu
+func (t detailsTab) scrollToSelection() detailsTab {
+	if len(t.shown) < 2 {
+		return t
+	}
+	_, hits := t.renderDetailsSection(contentLayout(t.width, t.height), t.height)
+	t.offset = hits.start
+	return t
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Périodes proposées par la touche de changement, dans l'ordre ; la période
+// personnalisée n'y figure qu'une fois saisie
+const (
+	periodAll    = iota // toutes les dates
+	period7             // 7 derniers jours
+	period30            // 30 derniers jours
+	period90            // 90 derniers jours
+	periodSeason        // saison balnéaire en cours, ou la dernière terminée
+	periodCustom        // dates saisies
+)
+
+// Durée en jours des périodes glissantes
+var periodDays = map[int]int{period7: 7, period30: 30, period90: 90}
+
+// Saison balnéaire par défaut, l'été austral : du 1er novembre au 31 mars,
+// modifiable dans la configuration (bathing_season)
+var bathingSeason = seasonDates{start: monthDay{time.November, 1}, end: monthDay{time.March, 31}}
+
+// Formats acceptés pour saisir une date, en plus de celui de la langue
+var periodInputLayouts = []string{"2006-01-02", "02/01/2006"}
+
+// monthDay est un jour de l'année sans l'année
+type monthDay struct {
+	month time.Month
+	day   int
+}
+
+// parseMonthDay lit un jour au format MM-JJ
+func parseMonthDay(s string) (monthDay, error) {
+	t, err := time.Parse("01-02", s)
+	if err != nil {
+		return monthDay{}, fmt.Errorf("%q n'est pas au format MM-JJ", s)
+	}
+	return monthDay{t.Month(), t.Day()}, nil
+}
+
+// in renvoie la date du jour pour une année
+func (d monthDay) in(year int, loc *time.Location) time.Time {
+	return time.Date(year, d.month, d.day, 0, 0, 0, 0, loc)
+}
+
+// seasonDates délimite la saison balnéaire, qui peut chevaucher deux années
+type seasonDates struct {
+	start, end monthDay
+}
+
+// around renvoie la saison en cours à la date now, ou la dernière terminée
+// hors saison ; to est exclu
+func (s seasonDates) around(now time.Time) (time.Time, time.Time) {
+	year, loc := now.Year(), now.Location()
+	from, to := s.start.in(year, loc), s.end.in(year, loc).AddDate(0, 0, 1)
+	if !to.After(from) {
+		// Saison à cheval sur deux années
+		to = to.AddDate(1, 0, 0)
+	}
+	for from.After(now) {
+		from, to = from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0)
+	}
+	return from, to
+}
+
+// datePeriod restreint les prélèvements affichés, exportés et résumés dans
+// les statistiques à une période
+type datePeriod struct {
+	kind     int
+	from, to time.Time // bornes de la période personnalisée (zéro : ouverte), to exclu
+}
+
+// bounds renvoie les bornes de la période à la date now ; une borne zéro
+// n'est pas limitée
+func (p datePeriod) bounds(now time.Time) (time.Time, time.Time) {
+	switch p.kind {
+	case period7, period30, period90:
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return day.AddDate(0, 0, 1-periodDays[p.kind]), time.Time{}
+	case periodSeason:
+		return bathingSeason.around(now)
+	case periodCustom:
+		return p.from, p.to
+	}
+	return time.Time{}, time.Time{}
+}
+
+// label décrit la période à la date now
+func (p datePeriod) label(now time.Time) string {
+	switch p.kind {
+	case period7, period30, period90:
+		return tr("%d derniers jours", periodDays[p.kind])
+	case periodSeason:
+		from, to := p.bounds(now)
+		if last := to.AddDate(0, 0, -1); last.Year() != from.Year() {
+			return tr("saison %d-%d", from.Year(), last.Year())
+		}
+		return tr("saison %d", from.Year())
+	case periodCustom:
+		switch {
+		case p.from.IsZero():
+			return tr("jusqu'au %s", formatDate(p.to.AddDate(0, 0, -1)))
+		case p.to.IsZero():
+			return tr("depuis le %s", formatDate(p.from))
+		}
+		return tr("du %s au %s", formatDate(p.from), formatDate(p.to.AddDate(0, 0, -1)))
+	}
+	return tr("toutes les dates")
+}
+
+// filter renvoie le filtre des prélèvements de la période à la date now ; les
+// prélèvements sans date lisible ne sont retenus que sans période
+func (p datePeriod) filter(now time.Time) sampleFilter {
+	if p.kind == periodAll {
+		return sampleFilter{}
+	}
+	from, to := p.bounds(now)
+	return sampleFilter{label: p.label(now), keep: func(s sample) bool {
+		return !s.when.IsZero() && !s.when.Before(from) && (to.IsZero() || s.when.Before(to))
+	}}
+}
+
+// next renvoie la période suivante de la touche de changement
+func (p datePeriod) next(custom datePeriod) datePeriod {
+	switch {
+	case p.kind == periodSeason && custom.kind == periodCustom:
+		return custom
+	case p.kind >= periodSeason:
+		return datePeriod{kind: periodAll}
+	}
+	return datePeriod{kind: p.kind + 1}
+}
+
+// periodMsg transmet aux onglets le filtre de la période choisie
+type periodMsg struct{ filter sampleFilter }
+
+// rows renvoie les lignes du CSV brut des détails retenues par le filtre,
+// en-tête compris
+func (f sampleFilter) rows(details [][]string) [][]string {
+	if f.keep == nil || len(details) == 0 {
+		return details
+	}
+	kept := [][]string{details[0]}
+	for _, s := range parseSamples(details) {
+		if f.keep(s) {
+			kept = append(kept, details[s.row])
+		}
+	}
+	return kept
+}
+
+// parseInputDate lit une date saisie ; une saisie vide renvoie la date zéro
+func parseInputDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range append(periodInputLayouts, activeLocale.date) {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New(tr("date illisible : %q", s))
+}
+
+// customPeriod construit la période personnalisée entre deux dates saisies,
+// incluses ; une date vide laisse la période ouverte de ce côté
+func customPeriod(from, to string) (datePeriod, error) {
+	p := datePeriod{kind: periodCustom}
+	var err error
+	if p.from, err = parseInputDate(from); err != nil {
+		return p, err
+	}
+	if p.to, err = parseInputDate(to); err != nil {
+		return p, err
+	}
+	switch {
+	case p.from.IsZero() && p.to.IsZero():
+		return p, errors.New(tr("indiquez au moins une date"))
+	case !p.to.IsZero():
+		p.to = p.to.AddDate(0, 0, 1)
+		if !p.from.IsZero() && !p.to.After(p.from) {
+			return p, errors.New(tr("la date de fin précède la date de début"))
+		}
+	}
+	return p, nil
+}
+
+// periodForm est la saisie de la période personnalisée : date de début et
+// date de fin
+type periodForm struct {
+	inputs [2]textinput.Model
+	focus  int
+	err    error
+}
+
+func newPeriodForm(p datePeriod) periodForm {
+	var f periodForm
+	for i := range f.inputs {
+		f.inputs[i] = textinput.New()
+		f.inputs[i].Placeholder = tr("AAAA-MM-JJ")
+		f.inputs[i].CharLimit = 20
+		f.inputs[i].Width = 20
+		// Curseur fixe : le modèle racine n'a pas à relayer le clignotement
+		f.inputs[i].Cursor.SetMode(cursor.CursorStatic)
+	}
+	f.inputs[0].Prompt, f.inputs[1].Prompt = tr("Du : "), tr("Au : ")
+	if p.kind == periodCustom {
+		if !p.from.IsZero() {
+			f.inputs[0].SetValue(p.from.Format("2006-01-02"))
+		}
+		if !p.to.IsZero() {
+			f.inputs[1].SetValue(p.to.AddDate(0, 0, -1).Format("2006-01-02"))
+		}
+	}
+	f.inputs[0].Focus()
+	return f
+}
+
+// Update fait défiler le champ actif avec Tab et les flèches, et transmet
+// les autres touches au champ
+func (f periodForm) Update(msg tea.KeyMsg) (periodForm, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "shift+tab", "up", "down":
+		f.inputs[f.focus].Blur()
+		f.focus = 1 - f.focus
+		return f, f.inputs[f.focus].Focus()
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return f, cmd
+}
+
+// period renvoie la période saisie
+func (f periodForm) period() (datePeriod, error) {
+	return customPeriod(f.inputs[0].Value(), f.inputs[1].Value())
+}
+
+// updatePeriodForm traite les touches de la saisie de période : Entrée
+// valide, Échap annule
+func (m Model) updatePeriodForm(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.showPeriodForm = false
+		return m, nil
+	case "enter":
+		p, err := m.periodForm.period()
+		if err != nil {
+			m.periodForm.err = err
+			return m, nil
+		}
+		m.showPeriodForm = false
+		m.customPeriod = p
+		return m.setPeriod(p)
+	}
+	var cmd tea.Cmd
+	m.periodForm, cmd = m.periodForm.Update(msg)
+	m.periodForm.err = nil
+	return m, cmd
+}
+
+// setPeriod applique une période aux onglets
+func (m Model) setPeriod(p datePeriod) (Model, tea.Cmd) {
+	m.period = p
+	m = m.addLog(slog.LevelInfo, tr("Période : %s", p.label(m.now)))
+	m, cmd := m.broadcast(periodMsg{p.filter(m.now)})
+	return m.syncSelection(), cmd
+}
+
+// renderPeriodPopup affiche la saisie de la période personnalisée
+func (m Model) renderPeriodPopup(l layout) string {
+	f := m.periodForm
+	lines := []string{
+		activeTheme.header().Render(tr("Période personnalisée")),
+		"",
+		f.inputs[0].View(),
+		f.inputs[1].View(),
+		"",
+	}
+	if f.err != nil {
+		lines = append(lines, activeTheme.level(2).Render(truncate(f.err.Error(), l.width-10)), "")
+	}
+	lines = append(lines, lipgloss.NewStyle().Faint(true).Render(tr("Date vide : période ouverte de ce côté")),
+		tr("Tab : champ suivant  Entrée : appliquer  Échap : annuler"))
+	return lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).BorderForeground(activeTheme.color(activeTheme.OK)).Padding(1, 4).MaxWidth(l.width).Render(strings.Join(lines, "\n"))
+}
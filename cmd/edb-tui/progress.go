@@ -0,0 +1,176 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Intervalle minimal entre deux envois d'avancement pour un même fichier
+const progressInterval = 100 * time.Millisecond
+
+// fetchProgressMsg donne l'avancement d'un fichier de la récupération id
+type fetchProgressMsg struct {
+	id       int
+	source   int   // index dans datasetFiles
+	received int64 // octets reçus
+	total    int64 // taille annoncée, -1 si inconnue
+	done     bool
+}
+
+// sourceProgress est l'avancement affiché pour un fichier
+type sourceProgress struct {
+	received int64
+	total    int64
+	started  bool
+	done     bool
+}
+
+// countingReader compte les octets lus et les signale au plus toutes les
+// progressInterval
+type countingReader struct {
+	r        io.Reader
+	total    int64
+	received int64
+	last     time.Time
+	report   func(received, total int64, done bool)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.received += int64(n)
+	if now := time.Now(); now.Sub(c.last) >= progressInterval {
+		c.last = now
+		c.report(c.received, c.total, false)
+	}
+	return n, err
+}
+
+// waitProgress attend le prochain avancement ; la commande ne renvoie rien
+// une fois la récupération terminée (canal fermé)
+func waitProgress(progress <-chan fetchProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-progress
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// beginFetch prépare une nouvelle récupération : spinner, avancement vide et
+// canal d'avancement
+func (m Model) beginFetch(now time.Time) Model {
+	m.fetching = true
+	m.fetchID++
+	m.fetchStarted = now
+	m.now = now
+	m.fetchDataset = m.dataset
+	m.fetchForced = false
+	m.progress = make([]sourceProgress, len(datasetFiles(selectedDatasets(m.fetchDataset))))
+	m.progressCh = make(chan fetchProgressMsg, 16)
+	return m
+}
+
+// fetchCmd lance la récupération préparée par beginFetch
+func (m Model) fetchCmd() tea.Cmd {
+	return tea.Batch(m.source(m.fetchID, m.fetchDataset, m.fetchForced, m.progressCh), waitProgress(m.progressCh), m.spinner.Tick)
+}
+
+// startFetch lance une récupération, sauf si une autre est déjà en cours :
+// les demandes rapprochées ne lancent pas plusieurs requêtes en parallèle
+func (m Model) startFetch(now time.Time) (Model, tea.Cmd) {
+	if m.fetching {
+		return m, nil
+	}
+	m = m.beginFetch(now)
+	return m, m.fetchCmd()
+}
+
+// forceFetch lance, comme startFetch, une récupération demandée par
+// l'utilisateur : elle contourne le cache partagé des sessions SSH
+func (m Model) forceFetch(now time.Time) (Model, tea.Cmd) {
+	if m.fetching {
+		return m, nil
+	}
+	m = m.beginFetch(now)
+	m.fetchForced = true
+	return m, m.fetchCmd()
+}
+
+// fetchDone termine la récupération en cours
+func (m Model) fetchDone() Model {
+	m.fetching = false
+	m.progressCh = nil
+	return m
+}
+
+// updateProgress enregistre l'avancement d'un fichier et attend le suivant
+func (m Model) updateProgress(msg fetchProgressMsg) (Model, tea.Cmd) {
+	if !m.fetching || msg.id != m.fetchID {
+		return m, nil
+	}
+	m.progress[msg.source] = sourceProgress{received: msg.received, total: msg.total, started: true, done: msg.done}
+	return m, waitProgress(m.progressCh)
+}
+
+// updateSpinner anime le spinner tant qu'une récupération est en cours
+func (m Model) updateSpinner(msg spinner.TickMsg) (Model, tea.Cmd) {
+	if !m.fetching {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	m.now = m.clock()
+	return m, cmd
+}
+
+// fetchStatus décrit la récupération en cours : fichiers, octets reçus et
+// durée écoulée
+func (m Model) fetchStatus() string {
+	var parts []string
+	selected := selectedDatasets(m.fetchDataset)
+	files := datasetFiles(selected)
+	for i, p := range m.progress {
+		part := files[i].label(selected)
+		switch {
+		case p.done:
+			part += " " + formatBytes(p.received) + " ✓"
+		case p.started && p.total > 0:
+			part += " " + formatBytes(p.received) + "/" + formatBytes(p.total)
+		case p.started:
+			part += " " + formatBytes(p.received)
+		default:
+			part += " …"
+		}
+		parts = append(parts, part)
+	}
+	elapsed := max(m.now.Sub(m.fetchStarted), 0)
+	return m.spinner.View() + " " + tr("Récupération : %s · %.1f s", strings.Join(parts, " · "), elapsed.Seconds())
+}
+
+// formatBytes formate une taille en octets, Ko ou Mo
+func formatBytes(n int64) string {
+	switch {
+	case n < 1<<10:
+		return tr("%d o", n)
+	case n < 1<<20:
+		return tr("%.1f Ko", float64(n)/(1<<10))
+	}
+	return tr("%.1f Mo", float64(n)/(1<<20))
+}
+
+// renderLoading remplace l'écran de chargement tant que les premières
+// données ne sont pas arrivées
+func (m Model) renderLoading(l layout) string {
+	text := tr("Chargement des données...")
+	if m.fetching {
+		text += "\n\n" + m.fetchStatus()
+	}
+	box := activeTheme.fg(activeTheme.Header).Align(lipgloss.Center).Render(text)
+	return lipgloss.Place(l.width, l.height, lipgloss.Center, lipgloss.Center, box)
+}
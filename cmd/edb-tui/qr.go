@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/skip2/go-qrcode"
+)
+
+// Lien vers les données source, encodé quand le QR code porte sur la source
+const sourceDataURL = "https://github.com/adriens/edb-noumea-data/blob/main/data/details.csv"
+
+// Taille en pixels des QR codes exportés en PNG
+const qrPNGSize = 512
+
+// Contenus possibles du QR code d'un point, dans l'ordre de la touche QR
+const (
+	qrGeo     = iota // position, en URI geo: (RFC 5870)
+	qrSummary        // résumé du dernier prélèvement
+	qrSource         // lien vers les données source
+)
+
+// Libellés des contenus du QR code
+var qrPayloadLabels = []string{"Position (geo:)", "Résumé du dernier prélèvement", "Données source"}
+
+// qrMsg demande au modèle racine d'afficher le QR code d'un point
+type qrMsg struct{ point pointHistory }
+
+// qrSavedMsg signale l'export du QR code en PNG
+type qrSavedMsg struct {
+	path string
+	err  error
+}
+
+// rowPoint reconstitue le point de prélèvement d'une ligne du tableau des
+// détails, avec ce seul prélèvement
+func rowPoint(header, row []string) pointHistory {
+	cell := func(name string) string {
+		if j := columnIndex(header, name); j >= 0 && j < len(row) {
+			return row[j]
+		}
+		return ""
+	}
+	s := sample{pointID: cell(idColumn), site: cell("Site"), point: cell("Point de prélèvement"), date: cell("Date"), ecoli: cell("E. coli"), ente: cell("Enté."), commune: cell("Commune")}
+	return pointHistory{key: s.pointKey(), site: s.site, point: s.point, samples: []sample{s}}
+}
+
+// beachQR est le QR code affiché pour un point de prélèvement
+type beachQR struct {
+	point   pointHistory
+	pos     geoPoint
+	located bool
+	payload int
+	source  string // page des données source du point
+
+	thresholds thresholdProfile // seuils du niveau résumé
+}
+
+// newBeachQR prépare le QR code d'un point du jeu de données selection, dont
+// le résumé donne le niveau selon les seuils du profil thresholds
+func newBeachQR(p pointHistory, selection string, thresholds thresholdProfile) beachQR {
+	d := p.dataset(selection)
+	pos, ok := locate(p, d)
+	q := beachQR{point: p, pos: pos, located: ok, payload: qrGeo, source: d.Page, thresholds: thresholds}
+	if !ok {
+		q.payload = qrSummary
+	}
+	return q
+}
+
+// next passe au contenu suivant ; la position n'est proposée que si elle est connue
+func (q beachQR) next() beachQR {
+	q.payload = (q.payload + 1) % len(qrPayloadLabels)
+	if q.payload == qrGeo && !q.located {
+		q.payload = qrSummary
+	}
+	return q
+}
+
+// place renvoie le nom de la plage suivi du point de prélèvement
+func (q beachQR) place() string {
+	if q.point.point == "" {
+		return q.point.site
+	}
+	return q.point.site + ", " + q.point.point
+}
+
+// content renvoie le texte encodé dans le QR code
+func (q beachQR) content() string {
+	switch q.payload {
+	case qrGeo:
+		return fmt.Sprintf("geo:%.5f,%.5f?q=%.5f,%.5f(%s)", q.pos.lat, q.pos.lon, q.pos.lat, q.pos.lon, url.PathEscape(q.point.site))
+	case qrSource:
+		return q.source
+	}
+	if len(q.point.samples) == 0 {
+		return q.place()
+	}
+	s := q.point.samples[0]
+	return tr("%s — %s : E. coli %s, Enté. %s (%s)", q.place(), s.date, s.ecoli, s.ente, tr(levelNames[sampleLevel(q.thresholds, s)]))
+}
+
+// qrFileName renvoie le chemin du PNG : out lui-même, ou un fichier nommé
+// d'après la plage si out est un dossier
+func qrFileName(out, place string) string {
+	if info, err := os.Stat(out); (err == nil && info.IsDir()) || strings.HasSuffix(out, string(os.PathSeparator)) {
+		slug := strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '-'
+		}, normalizeName(place))
+		return filepath.Join(out, strings.Trim(slug, "-")+".png")
+	}
+	return out
+}
+
+// exportQR enregistre le QR code en PNG
+func exportQR(path, content string) tea.Cmd {
+	return func() tea.Msg {
+		if dir := filepath.Dir(path); dir != "" {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return qrSavedMsg{path, err}
+			}
+		}
+		return qrSavedMsg{path, qrcode.WriteFile(content, qrcode.Medium, qrPNGSize, path)}
+	}
+}
+
+// showBeachQR ouvre la popup du QR code, et l'exporte si --qr-out est donné
+func (m Model) showBeachQR(q beachQR) (Model, tea.Cmd) {
+	m.qr = q
+	m.showQR = true
+	if m.qrOut == "" {
+		return m, nil
+	}
+	return m, exportQR(qrFileName(m.qrOut, q.point.site), q.content())
+}
+
+// qrSaved note l'export du QR code dans le journal
+func (m Model) qrSaved(msg qrSavedMsg) Model {
+	if msg.err != nil {
+		return m.addLog(slog.LevelWarn, tr("QR code non enregistré : %v", msg.err), "fichier", msg.path)
+	}
+	return m.addLog(slog.LevelInfo, tr("QR code enregistré dans %s", msg.path), "fichier", msg.path)
+}
+
+// renderQRPopup affiche le QR code du point sélectionné, avec son contenu en clair
+func (m Model) renderQRPopup(l layout) string {
+	content := m.qr.content()
+	lines := []string{activeTheme.header().Render(truncate(m.qr.place(), l.width-10)), ""}
+	qr, err := qrcode.New(content, qrcode.Low)
+	if err != nil {
+		lines = append(lines, tr("[QR code non disponible]"))
+	} else {
+		// Demi-blocs : deux modules par caractère en hauteur
+		text := strings.TrimRight(qr.ToSmallString(false), "\n")
+		// Bordure (2) + padding (2*1 vertical, 2*4 horizontal) + 6 lignes de texte
+		if w, h := lipgloss.Size(text); w+10 <= l.width && h+10 <= l.height {
+			lines = append(lines, text)
+		} else {
+			lines = append(lines, tr("Agrandissez le terminal pour afficher le QR code."))
+		}
+	}
+	label := tr(qrPayloadLabels[m.qr.payload]) + tr(" : ")
+	lines = append(lines, "",
+		lipgloss.NewStyle().Bold(true).Render(label)+truncate(content, l.width-10-runewidth.StringWidth(label)),
+		"",
+		shortHelp([]shortcut{newShortcut("Contenu suivant", keys.QR)})[0]+"  "+tr("Autre touche : fermer"))
+	return lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).BorderForeground(activeTheme.color(activeTheme.OK)).Padding(1, 4).Align(lipgloss.Center).MaxWidth(l.width).Render(strings.Join(lines, "\n"))
+}
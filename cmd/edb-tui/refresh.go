@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Intervalle par défaut et intervalle minimal du rafraîchissement automatique
+const (
+	defaultRefreshInterval = time.Hour
+	minRefreshInterval     = 10 * time.Second
+)
+
+// tickMsg est envoyé chaque seconde par l'unique minuterie de l'application :
+// elle fait avancer le compte à rebours et déclenche le rafraîchissement
+// automatique quand l'échéance est atteinte
+type tickMsg time.Time
+
+func tickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// handleTick met à jour l'heure courante, relance la récupération après un
+// échec et rafraîchit les données si l'échéance est passée ; la minuterie est toujours relancée une seule fois
+func (m Model) handleTick(now time.Time) (Model, tea.Cmd) {
+	m.now = now
+	if !m.nextRetry.IsZero() && !now.Before(m.nextRetry) {
+		m.nextRetry = time.Time{}
+		m = m.addLog(slog.LevelInfo, tr("Nouvel essai automatique après %d échec(s)", m.retries), "echecs", m.retries)
+		m, fetch := m.startFetch(now)
+		return m, tea.Batch(fetch, tickCmd())
+	}
+	if !m.autoRefresh || now.Before(m.nextRefresh) {
+		return m, tickCmd()
+	}
+	m = m.scheduleRefresh(now)
+	m = m.addLog(slog.LevelInfo, tr("Rafraîchissement automatique déclenché. Dernier : %s. Prochain : %s.", formatClock(m.lastRefresh), formatClock(m.nextRefresh)), "prochain", m.nextRefresh)
+	m, fetch := m.startFetch(now)
+	return m, tea.Batch(fetch, tickCmd())
+}
+
+// scheduleRefresh note un rafraîchissement à l'instant donné et repousse
+// l'échéance suivante d'un intervalle complet ; en pause, c'est le temps
+// restant à la reprise qui repart d'un intervalle complet
+func (m Model) scheduleRefresh(now time.Time) Model {
+	m.now = now
+	m.lastRefresh = now
+	m.nextRefresh = now.Add(m.refreshInterval)
+	if !m.autoRefresh {
+		m.pausedRemaining = m.refreshInterval
+	}
+	return m
+}
+
+// toggleAutoRefresh met en pause ou relance le rafraîchissement automatique ;
+// à la reprise, le compte à rebours repart du temps qui restait
+func (m Model) toggleAutoRefresh(now time.Time) Model {
+	m.now = now
+	m.autoRefresh = !m.autoRefresh
+	if !m.autoRefresh {
+		m.pausedRemaining = m.nextRefresh.Sub(now)
+		return m.addLog(slog.LevelInfo, tr("Rafraîchissement automatique en pause"))
+	}
+	m.nextRefresh = now.Add(max(m.pausedRemaining, 0))
+	return m.addLog(slog.LevelInfo, tr("Rafraîchissement automatique repris. Prochain : %s.", formatClock(m.nextRefresh)), "prochain", m.nextRefresh)
+}
+
+// countdown renvoie le temps restant avant le prochain rafraîchissement
+// automatique, ou l'indication de pause
+func (m Model) countdown() string {
+	if !m.autoRefresh {
+		return tr("auto en pause")
+	}
+	return tr("dans %s", formatRemaining(m.nextRefresh.Sub(m.now)))
+}
+
+// formatRemaining formate une durée en h:mm:ss ou mm:ss
+func formatRemaining(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	s := int(d.Round(time.Second) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%02d:%02d", s/60, s%60)
+}
+
+// Délais avant un nouvel essai après un échec de récupération : ils
+// s'allongent à chaque échec consécutif, puis restent au dernier
+var retryDelays = []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// fetchFailed garde les dernières données valides, affiche le bandeau
+// d'erreur et programme un nouvel essai
+func (m Model) fetchFailed(err error, now time.Time) (Model, tea.Cmd) {
+	m = m.fetchDone()
+	m.now = now
+	m.err = err
+	m.errDismissed = false
+	m.nextRetry = now.Add(retryDelays[min(m.retries, len(retryDelays)-1)])
+	m.retries++
+	m = m.addLog(slog.LevelError, errorMessage(err), "err", err, "type", errorKind(err), "echecs", m.retries, "nouvel_essai", m.nextRetry)
+	return m.resizeTabs()
+}
+
+// fetchSucceeded efface l'erreur éventuelle et le calendrier des nouveaux essais
+func (m Model) fetchSucceeded(fetchedAt time.Time) (Model, tea.Cmd) {
+	hadError := m.err != nil
+	m = m.fetchDone()
+	m.err = nil
+	m.retries = 0
+	m.nextRetry = time.Time{}
+	m.lastRefresh = fetchedAt
+	m.lastSuccess = fetchedAt
+	if !hadError {
+		return m, nil
+	}
+	return m.resizeTabs()
+}
+
+// retryNow relance immédiatement la récupération après un échec ; sans
+// erreur, ou pendant une récupération, la touche est sans effet
+func (m Model) retryNow(now time.Time) (Model, tea.Cmd) {
+	if m.err == nil || m.fetching {
+		return m, nil
+	}
+	m.now = now
+	m.nextRetry = time.Time{}
+	m = m.addLog(slog.LevelInfo, tr("Nouvel essai demandé"), "echecs", m.retries)
+	return m.forceFetch(now)
+}
+
+// dismissError masque le bandeau d'erreur ; l'indicateur de données
+// périmées et les nouveaux essais restent actifs
+func (m Model) dismissError() (Model, tea.Cmd) {
+	if m.err == nil || m.errDismissed {
+		return m, nil
+	}
+	m.errDismissed = true
+	return m.resizeTabs()
+}
+
+// retryStatus décrit le prochain essai automatique
+func (m Model) retryStatus() string {
+	if m.nextRetry.IsZero() {
+		return tr("nouvel essai en cours…")
+	}
+	return tr("nouvel essai dans %s", formatRemaining(m.nextRetry.Sub(m.now)))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// resumeTab est l'onglet Résumé : état sanitaire de chaque plage
+type resumeTab struct {
+	width  int
+	height int
+	data   [][]string // CSV brut du résumé
+	offset int        // première ligne de données affichée (défilement)
+}
+
+func newResumeTab() resumeTab {
+	return resumeTab{}
+}
+
+func (t resumeTab) Init() tea.Cmd { return nil }
+
+func (t resumeTab) title() string { return tr("Résumé") }
+
+func (t resumeTab) shortcuts() []shortcut {
+	return []shortcut{
+		newShortcut("Défiler", keys.Up, keys.Down),
+		{bindings: []key.Binding{keys.Top, keys.Bottom}, desc: "Début / fin", hidden: true},
+	}
+}
+
+func (t resumeTab) Update(msg tea.Msg) (tabModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+	case dataMsg:
+		t.data = msg.data
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Up):
+			t.offset--
+		case key.Matches(msg, keys.Down):
+			t.offset++
+		case key.Matches(msg, keys.Top):
+			t.offset = 0
+		case key.Matches(msg, keys.Bottom):
+			t.offset = len(t.data)
+		}
+	case tea.MouseMsg:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			t.offset -= wheelStep
+		case tea.MouseButtonWheelDown:
+			t.offset += wheelStep
+		}
+	}
+	t.offset = clampOffset(t.offset, t.visibleRows(), len(t.data)-1)
+	return t, nil
+}
+
+// visibleRows renvoie le nombre de plages affichables : bordure (2) + en-tête (1)
+func (t resumeTab) visibleRows() int {
+	return t.height - 3
+}
+
+func (t resumeTab) View() string {
+	if len(t.data) == 0 {
+		return ""
+	}
+	return lipgloss.PlaceHorizontal(t.width, lipgloss.Center, t.renderResumeTable(contentLayout(t.width, t.height)))
+}
+
+// clampOffset borne le défilement d'une liste de total lignes dont visible
+// lignes sont affichées
+func clampOffset(offset, visible, total int) int {
+	if offset > total-visible {
+		offset = total - visible
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
+
+// renderResumeTable affiche le tableau principal (plage / état sanitaire)
+func (t resumeTab) renderResumeTable(l layout) string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	cellStyle := activeTheme.fg(activeTheme.Text).Padding(0, 1)
+	greenStyle := activeTheme.fg(activeTheme.OK).Bold(true).Padding(0, 1)
+	borderStyle := lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(activeTheme.border()).Margin(0, 2)
+
+	header := make([]string, len(t.data[0]))
+	for j, cell := range t.data[0] {
+		switch cell {
+		case "plage":
+			cell = tr("Plage")
+		case "etat_sanitaire":
+			cell = tr("Status")
+		case communeColumn:
+			cell = tr("Commune")
+		}
+		header[j] = cell
+	}
+	colWidths := make([]int, len(header))
+	for rowIdx, row := range t.data {
+		for j, cell := range row {
+			if j >= len(colWidths) {
+				continue
+			}
+			if rowIdx == 0 {
+				cell = header[j]
+			} else if t.data[0][j] == "etat_sanitaire" {
+				cell = tr(cell)
+			}
+			if w := runewidth.StringWidth(cell); w > colWidths[j] {
+				colWidths[j] = w
+			}
+		}
+	}
+	// Bordure (2) + marge horizontale (2*2)
+	colWidths = fitColumns(colWidths, l.contentWidth, tableOverhead(len(colWidths))+6)
+
+	var rows []string
+	for rowIdx, row := range t.data {
+		if rowIdx > 0 && (rowIdx <= t.offset || rowIdx > t.offset+t.visibleRows()) {
+			continue
+		}
+		var cells []string
+		for j, cell := range row {
+			if j >= len(colWidths) {
+				continue
+			}
+			if rowIdx == 0 {
+				cells = append(cells, headerStyle.Render(padRight(truncate(header[j], colWidths[j]), colWidths[j])))
+				continue
+			}
+			if t.data[0][j] != "etat_sanitaire" {
+				cells = append(cells, cellStyle.Render(padRight(truncate(cell, colWidths[j]), colWidths[j])))
+				continue
+			}
+			// État sanitaire traduit quand le catalogue connaît la valeur
+			content := padRight(truncate(tr(cell), colWidths[j]), colWidths[j])
+			if cell == "Baignade autorisée" {
+				cells = append(cells, greenStyle.Render(content))
+			} else {
+				cells = append(cells, cellStyle.Render(content))
+			}
+		}
+		rows = append(rows, strings.Join(cells, " │ "))
+	}
+	return borderStyle.Render(strings.Join(rows, "\n"))
+}
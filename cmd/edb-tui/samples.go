@@ -0,0 +1,149 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Noms de colonnes acceptés pour chaque indicateur selon la version du CSV
+var (
+	ecoliColumns = []string{"E. coli", "e_coli_npp_100ml", "ec_npp_100ml"}
+	enteColumns  = []string{"Enté.", "enterocoques_npp_100ml", "ent_npp_100ml"}
+)
+
+// Formats de date acceptés pour les colonnes date et heure fusionnées
+var sampleTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"2006-01-02",
+	"02/01/2006",
+}
+
+// sample est un prélèvement du CSV des détails
+type sample struct {
+	row     int       // index de la ligne dans le CSV des détails
+	pointID string    // id_point_prelevement
+	site    string    // nom de la plage, sans le préfixe 'PLAGE DE '
+	point   string    // description du point de prélèvement
+	date    string    // date et heure telles qu'affichées
+	when    time.Time // date et heure interprétées (zéro si illisibles)
+	ecoli   string    // valeur brute E. coli
+	ente    string    // valeur brute Enté.
+	commune string    // commune des données agrégées, vide sinon
+}
+
+// pointKey identifie le point de prélèvement du sample
+func (s sample) pointKey() string {
+	if s.pointID != "" {
+		return s.pointID
+	}
+	return s.site + "|" + s.point
+}
+
+// columnIndex renvoie l'index de la première colonne portant l'un des noms, ou -1
+func columnIndex(header []string, names ...string) int {
+	for idx, col := range header {
+		for _, name := range names {
+			if col == name {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+// parseSamples lit les prélèvements du CSV brut des détails
+func parseSamples(details [][]string) []sample {
+	if len(details) < 2 {
+		return nil
+	}
+	header := details[0]
+	siteIdx := 0
+	idIdx := columnIndex(header, "id_point_prelevement")
+	descIdx := columnIndex(header, "desc_point_prelevement")
+	dateIdx := columnIndex(header, "date")
+	heureIdx := columnIndex(header, "heure")
+	ecoliIdx := columnIndex(header, ecoliColumns...)
+	enteIdx := columnIndex(header, enteColumns...)
+	communeIdx := columnIndex(header, communeColumn)
+	cell := func(row []string, idx int) string {
+		if idx >= 0 && idx < len(row) {
+			return strings.TrimSpace(row[idx])
+		}
+		return ""
+	}
+	samples := make([]sample, 0, len(details)-1)
+	for i, row := range details[1:] {
+		date := strings.TrimSpace(cell(row, dateIdx) + " " + cell(row, heureIdx))
+		samples = append(samples, sample{
+			row:     i + 1,
+			pointID: cell(row, idIdx),
+			site:    siteName(cell(row, siteIdx)),
+			point:   capitalize(cell(row, descIdx)),
+			date:    date,
+			when:    parseSampleTime(date),
+			ecoli:   cell(row, ecoliIdx),
+			ente:    cell(row, enteIdx),
+			commune: cell(row, communeIdx),
+		})
+	}
+	return samples
+}
+
+// parseSampleTime interprète la date et l'heure d'un prélèvement
+func parseSampleTime(s string) time.Time {
+	for _, layout := range sampleTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// pointHistory regroupe les prélèvements d'un point de prélèvement
+type pointHistory struct {
+	key     string
+	site    string
+	point   string
+	samples []sample // du plus récent au plus ancien
+}
+
+// dataset renvoie le jeu de données du point dans la sélection affichée,
+// d'après la commune de ses prélèvements
+func (p pointHistory) dataset(selection string) dataset {
+	commune := ""
+	if len(p.samples) > 0 {
+		commune = p.samples[0].commune
+	}
+	return datasetOf(commune, selection)
+}
+
+// groupByPoint regroupe les prélèvements par point, triés par site puis par point
+func groupByPoint(samples []sample) []pointHistory {
+	index := map[string]int{}
+	var points []pointHistory
+	for _, s := range samples {
+		key := s.pointKey()
+		i, ok := index[key]
+		if !ok {
+			i = len(points)
+			index[key] = i
+			points = append(points, pointHistory{key: key, site: s.site, point: s.point})
+		}
+		points[i].samples = append(points[i].samples, s)
+	}
+	for i := range points {
+		ps := points[i].samples
+		sort.SliceStable(ps, func(a, b int) bool { return ps[a].when.After(ps[b].when) })
+	}
+	sort.SliceStable(points, func(a, b int) bool {
+		if points[a].site != points[b].site {
+			return points[a].site < points[b].site
+		}
+		return points[a].point < points[b].point
+	})
+	return points
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/activeterm"
+	bm "github.com/charmbracelet/wish/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// Adresse d'écoute par défaut de la commande ssh-serve
+const sshDefaultAddr = ":2222"
+
+// Délai laissé aux sessions ouvertes pour se terminer à l'arrêt du serveur
+const sshShutdownTimeout = 10 * time.Second
+
+// sharedData est la récupération commune à toutes les sessions SSH : le
+// dernier résultat de chaque sélection de jeux de données est gardé en cache
+// et partagé par les sessions qui l'affichent. Chaque session le consulte à
+// son propre rafraîchissement automatique ; le cache n'est renouvelé qu'une
+// fois par intervalle, hors demandes manuelles.
+type sharedData struct {
+	mu       sync.Mutex
+	interval time.Duration
+	caches   map[string]*sharedCache // par sélection : nom d'un jeu ou allDatasets
+}
+
+// sharedCache est le cache d'une sélection
+type sharedCache struct {
+	last     tea.Msg   // dernier résultat : dataMsg ou erreur
+	lastAt   time.Time // date du dernier résultat
+	fetching bool
+	waiters  []chan tea.Msg // sessions en attente de la récupération en cours
+}
+
+func newSharedData(interval time.Duration) *sharedData {
+	return &sharedData{interval: interval, caches: map[string]*sharedCache{}}
+}
+
+// fetch est la source de données des sessions : le cache de la sélection,
+// rafraîchi s'il est périmé ou si force le demande
+func (s *sharedData) fetch(id int, selection string, force bool, progress chan<- fetchProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		return s.get(id, selection, force, progress)
+	}
+}
+
+// get renvoie le dernier résultat de la sélection s'il est récent, sinon
+// attend celui d'une nouvelle récupération ; force en lance une même si le
+// cache est récent. La session qui lance la récupération en reçoit
+// l'avancement sous son numéro id ; pour les autres, progress est fermé
+// aussitôt. Une erreur n'est gardée que minRefreshInterval, pour réessayer
+// vite sans multiplier les requêtes quand plusieurs sessions échouent
+// ensemble.
+func (s *sharedData) get(id int, selection string, force bool, progress chan<- fetchProgressMsg) tea.Msg {
+	s.mu.Lock()
+	c := s.caches[selection]
+	if c == nil {
+		c = &sharedCache{}
+		s.caches[selection] = c
+	}
+	if !force && c.last != nil {
+		maxAge := s.interval
+		if _, failed := c.last.(error); failed {
+			maxAge = minRefreshInterval
+		}
+		if time.Since(c.lastAt) < maxAge {
+			defer s.mu.Unlock()
+			close(progress)
+			return c.last
+		}
+	}
+	if c.fetching {
+		// Une récupération de la sélection est déjà en cours, même forcée :
+		// son résultat est assez frais
+		wait := make(chan tea.Msg, 1)
+		c.waiters = append(c.waiters, wait)
+		s.mu.Unlock()
+		close(progress)
+		return <-wait
+	}
+	c.fetching = true
+	s.mu.Unlock()
+
+	msg := fetchAllData(id, selection, force, progress)()
+	if err, failed := msg.(error); failed {
+		logger.Warn("Récupération partagée en échec", "jeu", selection, "err", err, "type", errorKind(err))
+	}
+
+	s.mu.Lock()
+	c.last, c.lastAt, c.fetching = msg, time.Now(), false
+	for _, w := range c.waiters {
+		w <- msg
+	}
+	c.waiters = nil
+	s.mu.Unlock()
+	return msg
+}
+
+// sessionEnv renvoie l'environnement transmis par le client SSH, complété du
+// type de terminal du PTY, pour détecter le terminal dans le presse-papiers
+func sessionEnv(sess ssh.Session, pty ssh.Pty) func(string) string {
+	environ := sess.Environ()
+	return func(key string) string {
+		if key == "TERM" && pty.Term != "" {
+			return pty.Term
+		}
+		for _, kv := range environ {
+			if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+				return v
+			}
+		}
+		return ""
+	}
+}
+
+// sessionProfile renvoie le profil de couleurs du terminal du client, d'après
+// son environnement (TERM, COLORTERM, NO_COLOR). Un terminal sans profil
+// (TERM absent ou dumb) reçoit au moins les séquences de l'interface.
+func sessionProfile(sess ssh.Session, pty ssh.Pty) colorprofile.Profile {
+	p := colorprofile.Env(append(sess.Environ(), "TERM="+pty.Term))
+	return max(p, colorprofile.Ascii)
+}
+
+// programHandler crée le programme d'une session : un modèle propre à la
+// session (sessionModel), à la taille de son PTY, alimenté par le cache
+// partagé. Le rendu, lipgloss v2 émettant toujours les couleurs du thème, est
+// ramené au profil de couleurs du client ; les séquences OSC 52 du
+// presse-papiers passent par la même sortie sérialisée, sans conversion.
+func programHandler(sessionModel func() Model) bm.ProgramHandler {
+	return func(sess ssh.Session) *tea.Program {
+		pty, _, ok := sess.Pty()
+		if !ok {
+			return nil
+		}
+		// Le serveur n'alloue pas de PTY : la session émule celui du client
+		out := &syncWriter{w: sess}
+		m := sessionModel()
+		m.clipboard = out
+		m.getenv = sessionEnv(sess, pty)
+		m.width, m.height = pty.Window.Width, pty.Window.Height
+		m = m.addLog(slog.LevelInfo, tr("Session SSH ouverte (%s)", sess.User()), "adresse", sess.RemoteAddr().String())
+		render := &colorprofile.Writer{Forward: out, Profile: sessionProfile(sess, pty)}
+		return tea.NewProgram(m, tea.WithInput(sess), tea.WithOutput(render), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	}
+}
+
+// logSessions journalise l'ouverture et la fin de chaque connexion
+func logSessions(next ssh.Handler) ssh.Handler {
+	return func(sess ssh.Session) {
+		start := time.Now()
+		logger.Info("Connexion SSH", "utilisateur", sess.User(), "adresse", sess.RemoteAddr().String())
+		next(sess)
+		logger.Info("Déconnexion SSH", "utilisateur", sess.User(), "adresse", sess.RemoteAddr().String(), "duree", time.Since(start).Round(time.Second))
+	}
+}
+
+// sshHostKeyPath renvoie le chemin par défaut de la clé d'hôte du serveur,
+// générée au premier démarrage dans le dossier des données d'état
+func sshHostKeyPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "edb-tui")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ssh_host_ed25519"), nil
+}
+
+// runSSHServe exécute la commande "edb ssh-serve" : sert l'interface aux
+// clients SSH jusqu'à SIGINT ou SIGTERM. Toutes les sessions partagent la
+// même récupération des données. Renvoie le code de sortie du programme.
+func runSSHServe(args []string) int {
+	fs := flag.NewFlagSet("ssh-serve", flag.ContinueOnError)
+	addr := fs.String("addr", sshDefaultAddr, "adresse d'écoute du serveur SSH")
+	authorizedKeys := fs.String("authorized-keys", "", "fichier authorized_keys des clés publiques autorisées ; par défaut, accès libre")
+	hostKey := fs.String("host-key", "", "clé d'hôte du serveur, créée si absente ; par défaut dans le dossier des données d'état")
+	lang := fs.String("lang", "", "langue de l'interface (fr, en) ; par défaut selon LANG")
+	dataset := fs.String("dataset", "", "jeu de données affiché (nom du registre, ou all) ; par défaut celui de la configuration")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *lang == "" {
+		*lang = envLang()
+	}
+	if err := setLocale(*lang); err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+		return 2
+	}
+	// Thème, langue et raccourcis sont ceux du serveur, communs à toutes les
+	// sessions et jamais modifiés ensuite ; seuils et jeu de données ne sont
+	// que les valeurs de départ de chaque session
+	cfg, err := loadConfig()
+	if err == nil {
+		err = cfg.apply(*dataset)
+	}
+	var interval time.Duration
+	if err == nil {
+		interval, err = cfg.refreshInterval()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Erreur de configuration : %v", err))
+		return 1
+	}
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	if *hostKey == "" {
+		if *hostKey, err = sshHostKeyPath(); err != nil {
+			fmt.Fprintln(os.Stderr, tr("Erreur: %v", err))
+			return 1
+		}
+	}
+	if logFile, err := openLogFile(); err != nil {
+		fmt.Fprintln(os.Stderr, tr("Journal non enregistré sur disque : %v", err))
+	} else {
+		defer logFile.Close()
+	}
+
+	var covariateErrs []error
+	covariates, covariateErrs = cfg.covariates()
+	for _, err := range covariateErrs {
+		logger.Warn(tr("Covariable non chargée : %v", err))
+	}
+	shared := newSharedData(interval)
+	sessionModel := func() Model {
+		m := newModel(time.Now, shared.fetch)
+		m.refreshInterval = interval
+		m.nextRefresh = m.lastRefresh.Add(interval)
+		return m
+	}
+
+	opts := []ssh.Option{
+		wish.WithAddress(*addr),
+		wish.WithHostKeyPath(*hostKey),
+		wish.WithMiddleware(
+			// Le profil de couleurs de chaque session est appliqué par
+			// programHandler, indépendamment de celui demandé au middleware
+			bm.MiddlewareWithProgramHandler(programHandler(sessionModel), termenv.Ascii),
+			activeterm.Middleware(),
+			logSessions,
+		),
+	}
+	if *authorizedKeys != "" {
+		if _, err := os.Stat(*authorizedKeys); err != nil {
+			fmt.Fprintln(os.Stderr, tr("Erreur: %v", err))
+			return 1
+		}
+		opts = append(opts, wish.WithAuthorizedKeys(*authorizedKeys))
+	}
+	server, err := wish.NewServer(opts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tr("Erreur: %v", err))
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+	fmt.Fprintln(os.Stderr, tr("Serveur SSH en écoute sur %s", *addr))
+	logger.Info("Serveur SSH démarré", "adresse", *addr, "authorized_keys", *authorizedKeys)
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, ssh.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+			fmt.Fprintln(os.Stderr, tr("Erreur: %v", err))
+			return 1
+		}
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), sshShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdown); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+			fmt.Fprintln(os.Stderr, tr("Erreur: %v", err))
+			return 1
+		}
+	}
+	logger.Info("Serveur SSH arrêté")
+	return 0
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// Largeur d'une ligne d'histogramme hors barre : libellé de la tranche,
+// symbole du niveau, " | " et compteur " (n)"
+const histoLabelWidth = 22
+
+// Nombre de tranches égales de l'histogramme linéaire sous le seuil le plus
+// haut, avant l'ajout du seuil bas comme borne
+const linearBins = 10
+
+// Bornes de l'histogramme en échelle logarithmique, complétées par les seuils
+// de l'indicateur pour qu'aucune tranche ne soit à cheval sur deux niveaux
+var logEdges = []int{10, 30, 100, 300, 1000, 3000, 10000}
+
+// Périmètres des statistiques, dans l'ordre de la touche de changement
+const (
+	scopeAll      = iota // tous les prélèvements, toutes dates confondues
+	scopeFilter          // prélèvements retenus par le filtre de l'onglet Détails
+	scopeSelected        // plage sélectionnée, dans le filtre de l'onglet Détails
+)
+
+// selectionMsg transmet à l'onglet Statistiques la plage sélectionnée et le
+// filtre courant de l'onglet Détails
+type selectionMsg struct {
+	site   string
+	filter sampleFilter
+}
+
+// sampleFilter retient une partie des prélèvements ; le filtre zéro les
+// retient tous
+type sampleFilter struct {
+	label string
+	keep  func(sample) bool
+}
+
+func (f sampleFilter) apply(samples []sample) []sample {
+	if f.keep == nil {
+		return samples
+	}
+	var kept []sample
+	for _, s := range samples {
+		if f.keep(s) {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// summary résume les valeurs d'un indicateur
+type summary struct {
+	count                 int
+	min, max              float64
+	mean, median, geomean float64
+	p90, p95              float64
+}
+
+// summarize calcule le résumé de valeurs positives ; la moyenne géométrique
+// compte les valeurs nulles comme 1 (limite de détection)
+func summarize(values []float64) summary {
+	s := summary{count: len(values)}
+	if len(values) == 0 {
+		return s
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	var sum, logSum float64
+	for _, v := range sorted {
+		sum += v
+		logSum += math.Log(math.Max(v, 1))
+	}
+	s.min, s.max = sorted[0], sorted[len(sorted)-1]
+	s.mean = sum / float64(len(sorted))
+	s.geomean = math.Exp(logSum / float64(len(sorted)))
+	s.median = percentile(sorted, 50)
+	s.p90 = percentile(sorted, 90)
+	s.p95 = percentile(sorted, 95)
+	return s
+}
+
+// percentile renvoie le p-ième centile de valeurs triées, par interpolation
+// linéaire entre les deux rangs les plus proches
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := min(lo+1, len(sorted)-1)
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// indicatorValues renvoie les valeurs utilisables d'un indicateur, les
+// valeurs censurées étant remplacées par leur limite
+func indicatorValues(samples []sample, colName string) []float64 {
+	var values []float64
+	for _, s := range samples {
+		if m := s.measure(colName); m.ok() {
+			values = append(values, m.number())
+		}
+	}
+	return values
+}
+
+// histoBin est une tranche d'histogramme [lo, hi) ; hi < 0 pour la tranche
+// de dépassement
+type histoBin struct {
+	lo, hi int
+	count  int
+}
+
+// histoEdges renvoie les bornes des tranches d'un indicateur selon les seuils
+// du profil p : dix tranches égales jusqu'au seuil haut, ou des tranches
+// logarithmiques. Les deux seuils
+// sont toujours des bornes, pour qu'aucune tranche ne soit à cheval sur deux
+// niveaux (seuil bas de 450 pour un seuil haut de 1000, par exemple).
+func histoEdges(p thresholdProfile, colName string, logScale bool) []int {
+	good, bad := p.limits(colName)
+	edges := []int{0, good, bad}
+	if logScale {
+		edges = append(edges, logEdges...)
+	} else {
+		for i := 1; i < linearBins; i++ {
+			edges = append(edges, i*bad/linearBins)
+		}
+	}
+	slices.Sort(edges)
+	return slices.Compact(edges)
+}
+
+// histogram répartit les valeurs dans les tranches ; les valeurs au-delà de
+// la dernière borne vont dans une tranche de dépassement
+func histogram(values []float64, edges []int) []histoBin {
+	bins := make([]histoBin, len(edges))
+	for i := range edges {
+		bins[i].lo = edges[i]
+		bins[i].hi = -1
+		if i+1 < len(edges) {
+			bins[i].hi = edges[i+1]
+		}
+	}
+	for _, v := range values {
+		// Une valeur égale à une borne haute reste dans la tranche du dessous,
+		// comme les seuils (≤ 500 est excellent)
+		i := 0
+		for i < len(edges)-1 && v > float64(edges[i+1]) {
+			i++
+		}
+		bins[i].count++
+	}
+	return bins
+}
+
+// label renvoie le libellé de la tranche : "100-200" ou "> 1000"
+func (b histoBin) label() string {
+	if b.hi < 0 {
+		return fmt.Sprintf("> %d", b.lo)
+	}
+	return fmt.Sprintf("%d-%d", b.lo, b.hi)
+}
+
+// level renvoie le niveau de qualité de la tranche selon les seuils du
+// profil p : les bornes suivent les seuils, toute la tranche a donc le même
+// niveau
+func (b histoBin) level(p thresholdProfile, colName string) int {
+	if b.hi < 0 {
+		return p.level(colName, b.lo+1)
+	}
+	return p.level(colName, b.hi)
+}
+
+// statsTab est l'onglet Statistiques : résumé et histogrammes des valeurs
+// E. coli et Enté. sur le périmètre choisi
+type statsTab struct {
+	width     int
+	height    int
+	details   [][]string // CSV brut des détails
+	samples   []sample
+	scope     int
+	logScale  bool
+	selection selectionMsg
+
+	thresholds thresholdProfile // profil de seuils choisi
+}
+
+// Les statistiques suivent par défaut le filtre de l'onglet Détails, comme
+// le tableau et les copies
+func newStatsTab() statsTab {
+	return statsTab{scope: scopeFilter, thresholds: defaultThresholds}
+}
+
+func (t statsTab) Init() tea.Cmd { return nil }
+
+func (t statsTab) title() string { return tr("Statistiques") }
+
+func (t statsTab) shortcuts() []shortcut {
+	return []shortcut{
+		newShortcut("Périmètre", keys.Scope),
+		newShortcut("Échelle log", keys.LogScale),
+	}
+}
+
+func (t statsTab) Update(msg tea.Msg) (tabModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width, t.height = msg.Width, msg.Height
+	case dataMsg:
+		t.details = msg.details
+		t.samples = parseSamples(msg.details)
+	case selectionMsg:
+		t.selection = msg
+	case thresholdsMsg:
+		t.thresholds = msg.profile
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Scope):
+			t.scope = (t.scope + 1) % 3
+		case key.Matches(msg, keys.LogScale):
+			t.logScale = !t.logScale
+		}
+	}
+	return t, nil
+}
+
+// scoped renvoie les prélèvements du périmètre choisi et son libellé
+func (t statsTab) scoped() ([]sample, string) {
+	switch t.scope {
+	case scopeFilter:
+		return t.selection.filter.apply(t.samples), tr("filtre de l'onglet Détails")
+	case scopeSelected:
+		site := t.selection.site
+		samples := t.selection.filter.apply(t.samples)
+		return sampleFilter{keep: func(s sample) bool { return s.site == site }}.apply(samples), tr("plage sélectionnée (%s)", site)
+	}
+	return t.samples, tr("tous les points, toutes les dates")
+}
+
+func (t statsTab) View() string {
+	samples, scopeLabel := t.scoped()
+	scale := tr("échelle linéaire")
+	if t.logScale {
+		scale = tr("échelle logarithmique")
+	}
+	status := tr("Périmètre : %s · %d prélèvements · %s", scopeLabel, len(samples), scale)
+	if label := t.selection.filter.label; label != "" && t.scope != scopeAll {
+		status += tr(" · période : %s", label)
+	}
+	if n := invalidMeasurements(samples); n > 0 {
+		status += tr(" · %d valeur(s) non numérique(s) ignorée(s)", n)
+	}
+	status = lipgloss.NewStyle().Faint(true).Render(truncate(status, t.width))
+
+	ecoli := indicatorValues(samples, "E. coli")
+	ente := indicatorValues(samples, "Enté.")
+	names, sums := []string{"E. coli", "Enté."}, []summary{summarize(ecoli), summarize(ente)}
+	table := renderSummaryTable(names, sums)
+
+	// Largeur des barres : ce qu'il reste une fois le libellé et le compteur
+	// retirés ; les deux histogrammes sont côte à côte si la place le permet
+	sideBySide := t.width/2-histoLabelWidth >= 12
+	barWidth := t.width - histoLabelWidth
+	if sideBySide {
+		barWidth = t.width/2 - histoLabelWidth - 2
+	}
+	barWidth = min(max(barWidth, 4), 40)
+	ecoliBlock := tr("Histogramme E. coli :") + "\n" + renderHistogram(t.thresholds, "E. coli", histogram(ecoli, histoEdges(t.thresholds, "E. coli", t.logScale)), barWidth)
+	enteBlock := tr("Histogramme Enté. :") + "\n" + renderHistogram(t.thresholds, "Enté.", histogram(ente, histoEdges(t.thresholds, "Enté.", t.logScale)), barWidth)
+	var histos string
+	if sideBySide {
+		histos = lipgloss.JoinHorizontal(lipgloss.Top, ecoliBlock, "    ", enteBlock)
+	} else {
+		histos = ecoliBlock + "\n\n" + enteBlock
+	}
+	// Sur un écran bas, le résumé tient sur une ligne par indicateur pour
+	// laisser la place aux histogrammes
+	if 2+lipgloss.Height(table)+1+lipgloss.Height(histos) > t.height {
+		table = renderCompactSummary(names, sums, t.width)
+	}
+	body := lipgloss.JoinVertical(lipgloss.Center, table, "", histos)
+	return status + "\n\n" + lipgloss.PlaceHorizontal(t.width, lipgloss.Center, body)
+}
+
+// renderSummaryTable affiche le résumé de chaque indicateur, une colonne par
+// indicateur
+func renderSummaryTable(names []string, sums []summary) string {
+	rows := [][]string{{""}}
+	for _, name := range names {
+		rows[0] = append(rows[0], tr(name))
+	}
+	metrics := []struct {
+		label string
+		value func(summary) string
+	}{
+		{"Prélèvements", func(s summary) string { return strconv.Itoa(s.count) }},
+		{"Minimum", func(s summary) string { return fmt.Sprintf("%.0f", s.min) }},
+		{"Médiane", func(s summary) string { return fmt.Sprintf("%.0f", s.median) }},
+		{"Moyenne", func(s summary) string { return fmt.Sprintf("%.1f", s.mean) }},
+		{"Moyenne géométrique", func(s summary) string { return fmt.Sprintf("%.1f", s.geomean) }},
+		{"P90", func(s summary) string { return fmt.Sprintf("%.0f", s.p90) }},
+		{"P95", func(s summary) string { return fmt.Sprintf("%.0f", s.p95) }},
+		{"Maximum", func(s summary) string { return fmt.Sprintf("%.0f", s.max) }},
+	}
+	for _, metric := range metrics {
+		row := []string{tr(metric.label)}
+		for _, s := range sums {
+			if s.count == 0 {
+				row = append(row, "-")
+			} else {
+				row = append(row, metric.value(s))
+			}
+		}
+		rows = append(rows, row)
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for j, cell := range row {
+			widths[j] = max(widths[j], runewidth.StringWidth(cell))
+		}
+	}
+	var lines []string
+	for i, row := range rows {
+		cells := []string{padRight(row[0], widths[0])}
+		for j := 1; j < len(row); j++ {
+			cells = append(cells, strings.Repeat(" ", widths[j]-runewidth.StringWidth(row[j]))+row[j])
+		}
+		line := strings.Join(cells, "   ")
+		if i == 0 {
+			line = lipgloss.NewStyle().Bold(true).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderCompactSummary affiche le résumé de chaque indicateur sur une ligne,
+// ou deux si la largeur manque
+func renderCompactSummary(names []string, sums []summary, width int) string {
+	var lines []string
+	for i, s := range sums {
+		parts := []string{
+			tr(names[i]) + tr(" : ") + fmt.Sprintf("n=%d", s.count),
+			tr("min %.0f", s.min), tr("méd. %.0f", s.median), tr("moy. %.1f", s.mean),
+			tr("géo. %.1f", s.geomean), fmt.Sprintf("P90 %.0f", s.p90), fmt.Sprintf("P95 %.0f", s.p95), tr("max %.0f", s.max),
+		}
+		lines = append(lines, wrapHints(parts, width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderHistogram affiche les tranches d'un indicateur, colorées selon leur
+// niveau de qualité dans le profil p
+func renderHistogram(p thresholdProfile, colName string, bins []histoBin, width int) string {
+	maxBin, labelWidth := 1, 0
+	for _, b := range bins {
+		maxBin = max(maxBin, b.count)
+		labelWidth = max(labelWidth, len(b.label()))
+	}
+	lines := []string{}
+	for _, b := range bins {
+		barLen := int(float64(b.count) / float64(maxBin) * float64(width))
+		if barLen < 1 && b.count > 0 {
+			barLen = 1
+		}
+		level := b.level(p, colName)
+		bar := activeTheme.fg(activeTheme.levelColor(level)).Render(strings.Repeat("█", barLen))
+		lines = append(lines, fmt.Sprintf("%*s %s | %s (%d)", labelWidth, b.label(), levelSymbols[level], bar, b.count))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// Les seuils sont des bornes des histogrammes, linéaires ou logarithmiques,
+// même quand le seuil bas ne tombe pas sur une tranche égale
+func TestHistoEdges(t *testing.T) {
+	local := thresholdProfile{name: "local", Ecoli: [2]int{450, 1000}, Ente: [2]int{200, 400}}
+
+	tests := []struct {
+		colName  string
+		logScale bool
+		want     []int
+	}{
+		{"E. coli", false, []int{0, 100, 200, 300, 400, 450, 500, 600, 700, 800, 900, 1000}},
+		{"Enté.", false, []int{0, 40, 80, 120, 160, 200, 240, 280, 320, 360, 400}},
+		{"E. coli", true, []int{0, 10, 30, 100, 300, 450, 1000, 3000, 10000}},
+	}
+	for _, tt := range tests {
+		got := histoEdges(local, tt.colName, tt.logScale)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("histoEdges(%q, %v) = %v, attendu %v", tt.colName, tt.logScale, got, tt.want)
+		}
+		// Chaque tranche n'a qu'un niveau : celui de ses valeurs extrêmes
+		for _, b := range histogram(nil, got) {
+			if b.hi < 0 {
+				continue
+			}
+			if lo, hi := local.level(tt.colName, b.lo+1), local.level(tt.colName, b.hi); lo != hi {
+				t.Errorf("%s : tranche %s à cheval sur les niveaux %d et %d", tt.colName, b.label(), lo, hi)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// Index des onglets dans la barre (touches 1 à 6 par défaut)
+const (
+	tabResume = iota
+	tabDetails
+	tabStats
+	tabHistory
+	tabJournal
+	tabMap
+)
+
+// tabModel est le sous-modèle Bubbletea d'un onglet. Il reçoit les messages
+// qui le concernent (données, taille, touches et souris relatives à sa zone)
+// et ne connaît rien de l'habillage dessiné par le modèle racine.
+type tabModel interface {
+	Init() tea.Cmd
+	Update(msg tea.Msg) (tabModel, tea.Cmd)
+	View() string
+	title() string         // libellé affiché dans la barre d'onglets
+	shortcuts() []shortcut // raccourcis propres à l'onglet, pour le pied de page et l'aide
+}
+
+// logEntryMsg transmet une nouvelle entrée du log à l'onglet Journal
+type logEntryMsg logEntry
+
+func newTabs() []tabModel {
+	return []tabModel{
+		newResumeTab(),
+		newDetailsTab(),
+		newStatsTab(),
+		newHistoryTab(),
+		newJournalTab(),
+		newMapTab(),
+	}
+}
+
+// switchTab affiche l'onglet demandé
+func (m Model) switchTab(idx int) Model {
+	if idx >= 0 && idx < len(m.tabs) {
+		m.activeTab = idx
+	}
+	return m.syncSelection()
+}
+
+// selection renvoie la plage sélectionnée et le filtre courant de l'onglet
+// Détails
+func (m Model) selection() selectionMsg {
+	details := m.tabs[tabDetails].(detailsTab)
+	return selectionMsg{site: details.selectedSite(), filter: details.period}
+}
+
+// syncSelection transmet à l'onglet Statistiques la sélection de l'onglet
+// Détails quand il est affiché : les statistiques peuvent porter sur la
+// plage sélectionnée ou sur le filtre courant
+func (m Model) syncSelection() Model {
+	if m.activeTab == tabStats {
+		m.tabs[tabStats], _ = m.tabs[tabStats].Update(m.selection())
+	}
+	return m
+}
+
+// updateActiveTab transmet un message à l'onglet affiché
+func (m Model) updateActiveTab(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.tabs[m.activeTab], cmd = m.tabs[m.activeTab].Update(msg)
+	return m, cmd
+}
+
+// broadcast transmet un message à tous les onglets
+func (m Model) broadcast(msg tea.Msg) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for i := range m.tabs {
+		var cmd tea.Cmd
+		m.tabs[i], cmd = m.tabs[i].Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// resizeTabs communique à chaque onglet la taille de sa zone de contenu, qui
+// dépend de la hauteur de son pied de page
+func (m Model) resizeTabs() (Model, tea.Cmd) {
+	l := computeLayout(m.width, m.height)
+	var cmds []tea.Cmd
+	for i, t := range m.tabs {
+		var cmd tea.Cmd
+		m.tabs[i], cmd = t.Update(tea.WindowSizeMsg{Width: l.contentWidth, Height: m.tabHeight(l, t)})
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// footer renvoie le pied de page, généré depuis la keymap : raccourcis de
+// l'onglet puis raccourcis globaux
+func (m Model) footer(l layout, t tabModel) string {
+	return wrapHints(shortHelp(append(t.shortcuts(), globalShortcuts()...)), l.contentWidth)
+}
+
+// tabHeight renvoie la hauteur disponible pour le contenu d'un onglet
+func (m Model) tabHeight(l layout, t tabModel) int {
+	// Box principale : bordure (2) + padding (2), en-tête, barre d'onglets,
+	// deux lignes vides autour du contenu et pied de page
+	h := l.height - l.logHeight() - 4 - m.headerHeight(l) - 1 - 2 - lipgloss.Height(m.footer(l, t))
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+// tabBar rend la barre d'onglets et renvoie la plage horizontale de chaque libellé
+func (m Model) tabBar(l layout) (string, [][2]int) {
+	activeStyle := activeTheme.activeTab().Padding(0, 1)
+	inactiveStyle := activeTheme.fg(activeTheme.Muted).Padding(0, 1)
+	var labels []string
+	var ranges [][2]int
+	x := 0
+	for i, t := range m.tabs {
+		label := fmt.Sprintf("%d %s", i+1, t.title())
+		// Sur les écrans étroits, seul l'onglet actif garde son libellé complet
+		if l.width < narrowWidth && i != m.activeTab {
+			label = fmt.Sprintf("%d", i+1)
+		}
+		style := inactiveStyle
+		if i == m.activeTab {
+			style = activeStyle
+		}
+		labels = append(labels, style.Render(label))
+		w := runewidth.StringWidth(label) + 2
+		ranges = append(ranges, [2]int{x, x + w})
+		x += w + 1
+	}
+	return strings.Join(labels, " "), ranges
+}
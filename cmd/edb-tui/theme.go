@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// palette associe une couleur à chaque rôle de l'interface : index ANSI
+// ("12"), couleur hexadécimale ("#0072B2") ou "" pour la couleur du terminal
+type palette struct {
+	Excellent    string `json:"excellent"`     // valeur sous le seuil "excellent"
+	Passable     string `json:"passable"`      // valeur entre les deux seuils
+	Interdit     string `json:"interdit"`      // baignade interdite
+	Header       string `json:"header"`        // titre, onglet actif, bordures de popup
+	Accent       string `json:"accent"`        // phrase de présentation
+	Point        string `json:"point"`         // point de prélèvement
+	Text         string `json:"text"`          // texte des tableaux
+	Muted        string `json:"muted"`         // onglets inactifs
+	Selected     string `json:"selected"`      // fond de la ligne sélectionnée
+	SelectedText string `json:"selected_text"` // texte de la ligne sélectionnée
+	Border       string `json:"border"`        // bordures secondaires, trait de côte
+	Frame        string `json:"frame"`         // bordure de la box principale
+	OK           string `json:"ok"`            // date de récupération, état sanitaire
+	Background   string `json:"background"`    // fond du titre
+}
+
+// Palettes intégrées, sélectionnables par leur nom dans la configuration
+var builtinPalettes = map[string]palette{
+	"dark": {
+		Excellent: "12", Passable: "3", Interdit: "1",
+		Header: "14", Accent: "11", Point: "12", Text: "15", Muted: "7",
+		Selected: "7", SelectedText: "0", Border: "8", Frame: "13", OK: "10", Background: "0",
+	},
+	"light": {
+		Excellent: "25", Passable: "130", Interdit: "160",
+		Header: "30", Accent: "90", Point: "25", Text: "0", Muted: "242",
+		Selected: "252", SelectedText: "0", Border: "245", Frame: "90", OK: "28", Background: "",
+	},
+	"high-contrast": {
+		Excellent: "14", Passable: "11", Interdit: "9",
+		Header: "15", Accent: "15", Point: "15", Text: "15", Muted: "15",
+		Selected: "15", SelectedText: "0", Border: "15", Frame: "15", OK: "10", Background: "0",
+	},
+	// Palette d'Okabe et Ito, lisible par les personnes daltoniennes
+	"okabe-ito": {
+		Excellent: "#0072B2", Passable: "#E69F00", Interdit: "#D55E00",
+		Header: "#56B4E9", Accent: "#F0E442", Point: "#0072B2", Text: "15", Muted: "7",
+		Selected: "7", SelectedText: "0", Border: "8", Frame: "#CC79A7", OK: "#009E73", Background: "0",
+	},
+}
+
+// Symboles des niveaux de qualité, pour ne pas reposer sur la seule couleur :
+// excellent, passable, baignade interdite
+var levelSymbols = []string{"●", "▲", "✖"}
+
+// Noms des niveaux de qualité
+var levelNames = []string{"excellent", "passable", "baignade interdite"}
+
+// theme est la palette utilisée pour le rendu. Sans couleur (NO_COLOR), la
+// sélection est affichée en vidéo inverse et les niveaux par leur symbole.
+type theme struct {
+	name string
+	palette
+	noColor bool
+}
+
+// activeTheme est le thème utilisé par le modèle racine et les onglets
+var activeTheme = theme{name: "dark", palette: builtinPalettes["dark"]}
+
+// themeNames renvoie les noms des thèmes intégrés, triés
+func themeNames() []string {
+	var names []string
+	for name := range builtinPalettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadTheme renvoie le thème intégré du nom donné, ou à défaut le fichier
+// themes/<nom>.json du dossier de configuration. Un fichier de thème ne
+// redéfinit que les rôles qu'il liste, à partir du thème "base" (dark par
+// défaut). La variable d'environnement NO_COLOR désactive les couleurs.
+func loadTheme(name string) (theme, error) {
+	if name == "" {
+		name = "dark"
+	}
+	t := theme{name: name, noColor: os.Getenv("NO_COLOR") != ""}
+	if p, ok := builtinPalettes[name]; ok {
+		t.palette = p
+		return t, nil
+	}
+	path, err := configPath()
+	if err != nil {
+		return t, err
+	}
+	path = filepath.Join(filepath.Dir(path), "themes", name+".json")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return t, fmt.Errorf("thème %q inconnu (thèmes intégrés : %s) : %w", name, strings.Join(themeNames(), ", "), err)
+	}
+	var file struct {
+		Base string `json:"base"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return t, fmt.Errorf("%s : %w", path, err)
+	}
+	if file.Base == "" {
+		file.Base = "dark"
+	}
+	base, ok := builtinPalettes[file.Base]
+	if !ok {
+		return t, fmt.Errorf("%s : thème de base %q inconnu", path, file.Base)
+	}
+	t.palette = base
+	if err := json.Unmarshal(b, &t.palette); err != nil {
+		return t, fmt.Errorf("%s : %w", path, err)
+	}
+	return t, nil
+}
+
+// color convertit une couleur de la palette, ou aucune couleur sans NO_COLOR
+func (t theme) color(c string) color.Color {
+	if t.noColor {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
+
+// fg renvoie un style de texte de la couleur donnée
+func (t theme) fg(c string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.color(c))
+}
+
+// levelColor renvoie la couleur d'un niveau de qualité (0, 1 ou 2)
+func (t theme) levelColor(level int) string {
+	return []string{t.Excellent, t.Passable, t.Interdit}[level]
+}
+
+// level renvoie le style d'une valeur selon son niveau de qualité
+func (t theme) level(level int) lipgloss.Style {
+	return t.fg(t.levelColor(level)).Bold(true)
+}
+
+// header renvoie le style des titres et en-têtes
+func (t theme) header() lipgloss.Style {
+	return t.fg(t.Header).Bold(true)
+}
+
+// selected renvoie le style de la ligne sélectionnée
+func (t theme) selected() lipgloss.Style {
+	if t.noColor {
+		return lipgloss.NewStyle().Reverse(true)
+	}
+	return lipgloss.NewStyle().Background(t.color(t.Selected)).Foreground(t.color(t.SelectedText))
+}
+
+// activeTab renvoie le style de l'onglet actif
+func (t theme) activeTab() lipgloss.Style {
+	if t.noColor {
+		return lipgloss.NewStyle().Bold(true).Reverse(true)
+	}
+	return lipgloss.NewStyle().Bold(true).Foreground(t.color(t.SelectedText)).Background(t.color(t.Header))
+}
+
+// border renvoie la couleur des bordures secondaires
+func (t theme) border() color.Color {
+	return t.color(t.Border)
+}
+
+// withSymbol ajoute le symbole du niveau de qualité à une valeur
+func withSymbol(value string, level int) string {
+	return value + " " + levelSymbols[level]
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// thresholdProfile donne les seuils "excellent" et "passable" de chaque
+// indicateur : au-dessus du second, la baignade est interdite. Les couleurs,
+// histogrammes, graphiques, la légende et les alertes en dépendent.
+type thresholdProfile struct {
+	name  string
+	Label string `json:"label"` // libellé affiché dans la légende
+	Ecoli [2]int `json:"ecoli"`
+	Ente  [2]int `json:"ente"`
+}
+
+// Profils de seuils, dans l'ordre de la touche de changement. Le profil
+// "local" n'est proposé que s'il est défini dans la configuration.
+var thresholdProfileNames = []string{"eu-inland", "eu-coastal", "who", "local"}
+
+// Profils intégrés, sélectionnables par leur nom dans la configuration
+var builtinThresholds = map[string]thresholdProfile{
+	// Seuils historiques de l'application : valeurs de la directive pour
+	// les eaux intérieures (qualité excellente puis bonne)
+	"eu-inland":  {Label: "Directive 2006/7/CE, eaux intérieures", Ecoli: [2]int{500, 1000}, Ente: [2]int{200, 400}},
+	"eu-coastal": {Label: "Directive 2006/7/CE, eaux côtières", Ecoli: [2]int{250, 500}, Ente: [2]int{100, 200}},
+	// L'OMS ne fixe de valeurs que pour les entérocoques (catégories A-B,
+	// C et D) ; E. coli garde les seuils des eaux intérieures
+	"who": {Label: "Valeurs guides OMS (2003)", Ecoli: [2]int{500, 1000}, Ente: [2]int{200, 500}},
+}
+
+// defaultThresholds est le profil de seuils choisi au démarrage par la
+// configuration ; chaque modèle garde ensuite son propre profil
+var defaultThresholds = withName(builtinThresholds["eu-inland"], "eu-inland")
+
+// thresholdsMsg transmet aux onglets le profil de seuils choisi
+type thresholdsMsg struct{ profile thresholdProfile }
+
+// localThresholds est le profil local de la configuration, s'il est défini
+var localThresholds *thresholdProfile
+
+func withName(p thresholdProfile, name string) thresholdProfile {
+	p.name = name
+	return p
+}
+
+// loadThresholds renvoie le profil de seuils nommé ; "" désigne le profil
+// par défaut
+func loadThresholds(name string) (thresholdProfile, error) {
+	if name == "" {
+		name = "eu-inland"
+	}
+	if name == "local" {
+		if localThresholds == nil {
+			return thresholdProfile{}, fmt.Errorf("profil de seuils local non défini (local_thresholds)")
+		}
+		return *localThresholds, nil
+	}
+	p, ok := builtinThresholds[name]
+	if !ok {
+		return thresholdProfile{}, fmt.Errorf("profil de seuils inconnu %q (profils : %s)", name, strings.Join(thresholdProfileNames, ", "))
+	}
+	return withName(p, name), nil
+}
+
+// validate vérifie que les seuils de chaque indicateur sont croissants
+func (p thresholdProfile) validate() error {
+	for _, limits := range [][2]int{p.Ecoli, p.Ente} {
+		if limits[0] <= 0 || limits[1] <= limits[0] {
+			return fmt.Errorf("seuils %v : deux valeurs croissantes et positives attendues", limits)
+		}
+	}
+	return nil
+}
+
+// level renvoie le niveau d'une valeur E. coli ou Enté. selon les seuils :
+// 0 (excellent), 1 (passable) ou 2 (baignade interdite)
+func (p thresholdProfile) level(colName string, n int) int {
+	good, bad := p.limits(colName)
+	switch {
+	case n <= good:
+		return 0
+	case n <= bad:
+		return 1
+	}
+	return 2
+}
+
+// limits renvoie les seuils d'un indicateur
+func (p thresholdProfile) limits(colName string) (int, int) {
+	if colName == "Enté." {
+		return p.Ente[0], p.Ente[1]
+	}
+	return p.Ecoli[0], p.Ecoli[1]
+}
+
+// nextThresholds renvoie le profil suivant de la touche de changement
+func nextThresholds(p thresholdProfile) thresholdProfile {
+	i := slices.Index(thresholdProfileNames, p.name)
+	for {
+		i = (i + 1) % len(thresholdProfileNames)
+		if next, err := loadThresholds(thresholdProfileNames[i]); err == nil {
+			return next
+		}
+	}
+}
+
+// exceedingPoints renvoie les points dont le dernier prélèvement dépasse le
+// seuil haut d'un indicateur du profil p
+func exceedingPoints(p thresholdProfile, details [][]string) []string {
+	var names []string
+	for _, point := range groupByPoint(parseSamples(details)) {
+		for _, colName := range []string{"E. coli", "Enté."} {
+			if m := point.samples[0].measure(colName); m.ok() && m.level(p, colName) == 2 {
+				names = append(names, point.site+" – "+point.point)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// thresholdAlert signale dans le journal les points au-dessus du seuil haut
+// du profil actif
+func (m Model) thresholdAlert() Model {
+	points := exceedingPoints(m.thresholds, m.details)
+	if len(points) == 0 {
+		return m
+	}
+	return m.addLog(slog.LevelWarn, tr("Seuil dépassé au dernier prélèvement (%s) : %s", tr(m.thresholds.Label), strings.Join(points, ", ")), "points", len(points), "seuils", m.thresholds.name)
+}
+
+// switchThresholds passe au profil de seuils suivant, transmis aux onglets
+func (m Model) switchThresholds() (Model, tea.Cmd) {
+	m.thresholds = nextThresholds(m.thresholds)
+	m = m.addLog(slog.LevelInfo, tr("Seuils : %s", tr(m.thresholds.Label)), "seuils", m.thresholds.name)
+	m, cmd := m.broadcast(thresholdsMsg{m.thresholds})
+	return m.thresholdAlert(), cmd
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Tendance d'un point de prélèvement sur ses derniers prélèvements
+const (
+	trendNone   = iota // trop peu de prélèvements
+	trendStable        // pas de tendance détectée
+	trendUp            // hausse probable
+	trendDown          // baisse probable
+	trendWorse         // hausse statistiquement significative : dégradation
+)
+
+// Valeurs de la colonne Tendance et flèches affichées
+var (
+	trendNames  = []string{"", "stable", "hausse", "baisse", "dégradation"}
+	trendArrows = []string{"", "→", "↗", "↘", "↗ ⚠"}
+)
+
+const (
+	trendWindow     = 12 // derniers prélèvements pris en compte
+	trendMinSamples = 6  // en dessous, pas de tendance
+	// Seuils de la statistique Z de Mann-Kendall : tendance à 20 % (flèche)
+	// et à 5 % (dégradation signalée), en test bilatéral
+	trendZ       = 1.282
+	trendSignifZ = 1.96
+)
+
+// indicatorTrend est la tendance d'un indicateur : test de Mann-Kendall et
+// pente de Theil-Sen sur le log10 des valeurs, dans l'ordre chronologique
+type indicatorTrend struct {
+	count  int
+	z      float64 // statistique S de Mann-Kendall normalisée
+	change float64 // pente de Theil-Sen rapportée à la période, en log10
+}
+
+// pointTrend est la tendance d'un point : celle de l'indicateur qui se dégrade
+// le plus
+type pointTrend struct {
+	kind       int
+	indicators map[string]indicatorTrend
+}
+
+// trendOf calcule la tendance d'un point sur ses derniers prélèvements
+func trendOf(p pointHistory) pointTrend {
+	t := pointTrend{kind: trendNone, indicators: map[string]indicatorTrend{}}
+	zMax, zMin := math.Inf(-1), math.Inf(1)
+	for _, colName := range []string{"E. coli", "Enté."} {
+		it := indicatorTrendOf(p.samples, colName)
+		t.indicators[colName] = it
+		if it.count >= trendMinSamples {
+			zMax, zMin = math.Max(zMax, it.z), math.Min(zMin, it.z)
+		}
+	}
+	// Une hausse l'emporte sur une baisse de l'autre indicateur
+	switch {
+	case math.IsInf(zMax, -1):
+		return t
+	case zMax >= trendSignifZ:
+		t.kind = trendWorse
+	case zMax >= trendZ:
+		t.kind = trendUp
+	case zMin <= -trendZ:
+		t.kind = trendDown
+	default:
+		t.kind = trendStable
+	}
+	return t
+}
+
+// indicatorTrendOf calcule la tendance d'un indicateur sur les trendWindow
+// derniers prélèvements utilisables (samples est du plus récent au plus
+// ancien) ; sans dates lisibles, les prélèvements sont supposés réguliers
+func indicatorTrendOf(samples []sample, colName string) indicatorTrend {
+	var xs, ys []float64
+	dated := true
+	for _, s := range samples {
+		if len(ys) == trendWindow {
+			break
+		}
+		m := s.measure(colName)
+		if !m.ok() {
+			continue
+		}
+		ys = append(ys, math.Log10(math.Max(m.number(), 1)))
+		xs = append(xs, float64(s.when.Unix()))
+		dated = dated && !s.when.IsZero()
+	}
+	slices.Reverse(xs)
+	slices.Reverse(ys)
+	if !dated {
+		for i := range xs {
+			xs[i] = float64(i)
+		}
+	}
+	t := indicatorTrend{count: len(ys)}
+	if t.count < 2 {
+		return t
+	}
+	t.z = mannKendall(ys)
+	t.change = theilSen(xs, ys) * (xs[len(xs)-1] - xs[0])
+	return t
+}
+
+// mannKendall renvoie la statistique S du test de Mann-Kendall normalisée (Z),
+// avec correction de continuité et des ex aequo
+func mannKendall(ys []float64) float64 {
+	n := len(ys)
+	s := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			switch {
+			case ys[j] > ys[i]:
+				s++
+			case ys[j] < ys[i]:
+				s--
+			}
+		}
+	}
+	variance := float64(n*(n-1)*(2*n+5)) / 18
+	ties := map[float64]int{}
+	for _, y := range ys {
+		ties[y]++
+	}
+	for _, t := range ties {
+		variance -= float64(t*(t-1)*(2*t+5)) / 18
+	}
+	if variance <= 0 {
+		return 0
+	}
+	switch {
+	case s > 0:
+		return float64(s-1) / math.Sqrt(variance)
+	case s < 0:
+		return float64(s+1) / math.Sqrt(variance)
+	}
+	return 0
+}
+
+// theilSen renvoie la médiane des pentes entre toutes les paires de points
+func theilSen(xs, ys []float64) float64 {
+	var slopes []float64
+	for i := range xs {
+		for j := i + 1; j < len(xs); j++ {
+			if xs[j] != xs[i] {
+				slopes = append(slopes, (ys[j]-ys[i])/(xs[j]-xs[i]))
+			}
+		}
+	}
+	if len(slopes) == 0 {
+		return 0
+	}
+	slices.Sort(slopes)
+	return percentile(slopes, 50)
+}
+
+// trendCell renvoie le texte affiché d'une cellule de la colonne Tendance
+func trendCell(cell string) string {
+	if kind := slices.Index(trendNames, cell); kind > trendNone {
+		return trendArrows[kind]
+	}
+	return cell
+}
+
+// trendStyle renvoie le style d'une cellule de la colonne Tendance : la
+// dégradation est signalée avec la couleur de la baignade interdite
+func trendStyle(cell string) lipgloss.Style {
+	switch slices.Index(trendNames, cell) {
+	case trendWorse:
+		return activeTheme.level(2).Bold(true)
+	case trendUp:
+		return activeTheme.level(1)
+	}
+	return activeTheme.fg(activeTheme.Text)
+}
+
+// headline résume la tendance sur une ligne
+func (t pointTrend) headline() string {
+	if t.kind == trendNone {
+		return tr("Tendance : moins de %d prélèvements", trendMinSamples)
+	}
+	return tr("Tendance : %s %s", trendArrows[t.kind], tr(trendNames[t.kind]))
+}
+
+// explain détaille la tendance de chaque indicateur
+func (t pointTrend) explain() []string {
+	if t.kind == trendNone {
+		return []string{t.headline()}
+	}
+	// Un point suivi depuis peu a moins de trendWindow prélèvements : le
+	// nombre affiché est celui réellement testé
+	var count int
+	var lines []string
+	for _, colName := range []string{"E. coli", "Enté."} {
+		it := t.indicators[colName]
+		if it.count < trendMinSamples {
+			continue
+		}
+		count = max(count, it.count)
+		lines = append(lines, tr("%s : Z %+.2f, Theil-Sen ×%s sur la période", tr(colName), it.z, formatFactor(math.Pow(10, it.change))))
+	}
+	return append([]string{tr("Mann-Kendall sur les %d derniers prélèvements :", count)}, lines...)
+}
+
+// formatFactor formate un facteur multiplicatif : une décimale sous 10
+func formatFactor(f float64) string {
+	if f < 10 {
+		return fmt.Sprintf("%.1f", f)
+	}
+	return fmt.Sprintf("%.0f", f)
+}
+
+// trendPoints calcule la tendance de chaque point, indexée par sa clé (voir
+// rowPointKey)
+func trendPoints(details [][]string) map[string]pointTrend {
+	trends := map[string]pointTrend{}
+	for _, p := range groupByPoint(parseSamples(details)) {
+		trends[p.key] = trendOf(p)
+	}
+	return trends
+}
+
+// degradingPoints renvoie les points dont la dégradation est significative
+func degradingPoints(details [][]string) []string {
+	var names []string
+	for _, p := range groupByPoint(parseSamples(details)) {
+		if trendOf(p).kind == trendWorse {
+			names = append(names, p.site+" – "+p.point)
+		}
+	}
+	return names
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Z de Mann-Kendall avec correction de continuité et des ex aequo
+func TestMannKendall(t *testing.T) {
+	tests := []struct {
+		ys   []float64
+		want float64
+	}{
+		// S = 10, Var = 5·4·15/18
+		{[]float64{1, 2, 3, 4, 5}, 9 / math.Sqrt(50.0/3)},
+		// S = -3, Var = 3·2·11/18
+		{[]float64{5, 4, 3}, -2 / math.Sqrt(11.0/3)},
+		// S = 5, Var = 4·3·13/18 moins 2·1·9/18 pour la paire d'ex aequo
+		{[]float64{1, 2, 2, 3}, 4 / math.Sqrt(23.0/3)},
+		// Série constante : variance nulle
+		{[]float64{2, 2, 2}, 0},
+		{[]float64{1, 2, 1}, 0},
+	}
+	for _, tt := range tests {
+		if got := mannKendall(tt.ys); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("mannKendall(%v) = %v, attendu %v", tt.ys, got, tt.want)
+		}
+	}
+}
+
+// La pente de Theil-Sen est la médiane des pentes : une valeur isolée ne la
+// déplace pas
+func TestTheilSen(t *testing.T) {
+	tests := []struct {
+		xs, ys []float64
+		want   float64
+	}{
+		{[]float64{0, 1, 2, 3}, []float64{1, 3, 5, 7}, 2},
+		{[]float64{0, 1, 2, 3, 4}, []float64{0, 1, 2, 3, 40}, 1},
+		{[]float64{1, 1}, []float64{1, 5}, 0},
+	}
+	for _, tt := range tests {
+		if got := theilSen(tt.xs, tt.ys); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("theilSen(%v, %v) = %v, attendu %v", tt.xs, tt.ys, got, tt.want)
+		}
+	}
+}
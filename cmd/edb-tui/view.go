@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/skip2/go-qrcode"
+)
+
+const repoURL = "https://github.com/adriens/edb-noumea-tui"
+
+func (m Model) View() string {
+	l := computeLayout(m.width, m.height)
+	if l.tooSmall {
+		return m.renderTooSmall(l)
+	}
+	if popup := m.renderPopup(l); popup != "" {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, popup)
+	}
+
+	if len(m.data) == 0 {
+		if m.err != nil {
+			return m.renderFetchError(l)
+		}
+		return m.renderLoading(l)
+	}
+	return m.renderMain(l)
+}
+
+// renderPopup renvoie la popup ouverte, ou "" si aucune
+func (m Model) renderPopup(l layout) string {
+	switch {
+	case m.showAbout:
+		return m.renderAboutPopup(l)
+	case m.showLegendPopup:
+		return lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).BorderForeground(activeTheme.color(activeTheme.Header)).Padding(2, 6).Align(lipgloss.Left).Width(l.popupWidth(70)).Render(legendText(m.thresholds) + "\n\n" + tr("Appuyez sur une touche pour fermer."))
+	case m.showHelp:
+		return m.renderHelpPopup(l)
+	case m.showQR:
+		return m.renderQRPopup(l)
+	case m.showCompare:
+		return m.renderComparePopup(l)
+	case m.showPeriodForm:
+		return m.renderPeriodPopup(l)
+	}
+	return ""
+}
+
+// renderHelpPopup affiche l'aide complète, générée depuis la keymap :
+// raccourcis globaux puis raccourcis de l'onglet affiché
+func (m Model) renderHelpPopup(l layout) string {
+	active := m.tabs[m.activeTab]
+	sections := []struct {
+		title     string
+		shortcuts []shortcut
+	}{
+		{tr("Général"), globalShortcuts()},
+		{tr("Onglet %s", active.title()), active.shortcuts()},
+	}
+	keyWidth := 0
+	for _, section := range sections {
+		for _, s := range section.shortcuts {
+			keyWidth = max(keyWidth, runewidth.StringWidth(s.full()))
+		}
+	}
+	titleStyle := activeTheme.header()
+	keyStyle := lipgloss.NewStyle().Bold(true)
+	var lines []string
+	for _, section := range sections {
+		var rows []string
+		for _, s := range section.shortcuts {
+			if len(s.enabled()) > 0 {
+				rows = append(rows, keyStyle.Render(padRight(s.full(), keyWidth))+"  "+tr(s.desc))
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, titleStyle.Render(section.title))
+		lines = append(lines, rows...)
+	}
+	lines = append(lines, "", tr("Appuyez sur une touche pour fermer."))
+	// Bordure (2) + padding horizontal (2*4)
+	return lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).BorderForeground(activeTheme.color(activeTheme.Header)).Padding(1, 4).Align(lipgloss.Left).MaxWidth(l.width).Render(strings.Join(lines, "\n"))
+}
+
+// renderMain rend l'habillage (titre, barre d'onglets, pied de page, logs)
+// autour du contenu de l'onglet affiché
+func (m Model) renderMain(l layout) string {
+	// Zone d'information sur la date/heure de récupération des données
+	fetchText, fetchStyle := m.fetchInfo()
+	fetchInfo := fetchStyle.Render(truncate(fetchText, l.contentWidth-2))
+
+	// Log section (affichée en dehors de la box principale)
+	logInfo := tr("Dernier refresh : %s | Prochain : %s", formatDateTime(m.lastRefresh), m.countdown())
+	// Bordure (2) + padding horizontal (2*2) de la zone de log, de largeur m.width-2
+	logLines := []string{truncate(logInfo, m.width-8)}
+	logs := m.logs
+	if len(logs) > l.logLines {
+		logs = logs[len(logs)-l.logLines:]
+	}
+	for _, entry := range logs {
+		logLines = append(logLines, entry.style().Render(truncate(entry.String(), m.width-8)))
+	}
+	logBox := lipgloss.NewStyle().Border(lipgloss.NormalBorder()).BorderForeground(activeTheme.border()).Padding(0, 2).Margin(0, 0).Width(m.width - 2).Height(l.logHeight()).Render(strings.Join(logLines, "\n"))
+
+	// Phrase de présentation et titre centrés façon btop, tronqués si besoin
+	intro := tr("edb-noumea-tui : le premier tui Glamour en Go pour consulter la qualité des eaux de baignade à %s", datasetLabel(m.displayedDataset()))
+	introStyle := activeTheme.fg(activeTheme.Accent).Bold(true).Italic(true).Background(activeTheme.color(activeTheme.Background)).Padding(0, 1)
+	renderedIntro := introStyle.Render(truncate(intro, l.contentWidth-2))
+
+	appTitle := tr("Eaux de baignade - %s", datasetLabel(m.displayedDataset()))
+	if m.period.kind != periodAll {
+		appTitle += " · " + m.period.label(m.now)
+	}
+	titleStyle := activeTheme.header().Background(activeTheme.color(activeTheme.Background)).Padding(0, 1)
+	renderedTitle := titleStyle.Render(truncate(appTitle, l.contentWidth-2))
+
+	var header []string
+	if l.showIntro {
+		header = append(header, renderedIntro)
+	}
+	header = append(header, renderedTitle, fetchInfo)
+	if m.showBanner() {
+		header = append(header, m.renderBanner(l))
+	}
+	bar, _ := m.tabBar(l)
+	header = append(header, bar, "")
+
+	// Contenu de l'onglet, calé sur la hauteur disponible pour que le pied
+	// de page reste en bas de la box
+	active := m.tabs[m.activeTab]
+	tabHeight := m.tabHeight(l, active)
+	content := lipgloss.NewStyle().MaxHeight(tabHeight).Render(active.View())
+	content = lipgloss.PlaceVertical(tabHeight, lipgloss.Top, content)
+
+	var lines []string
+	for _, section := range header {
+		lines = append(lines, lipgloss.PlaceHorizontal(l.contentWidth, lipgloss.Center, section))
+	}
+	lines = append(lines, lipgloss.PlaceHorizontal(l.contentWidth, lipgloss.Left, content), "")
+	lines = append(lines, lipgloss.PlaceHorizontal(l.contentWidth, lipgloss.Center, m.footer(l, active)))
+
+	// Encapsule tout le contenu dans une box façon btop (sans la zone de log)
+	mainContent := strings.Join(lines, "\n")
+	outerBox := lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).BorderForeground(activeTheme.color(activeTheme.Frame)).Padding(1, 2).Margin(0, 0).Width(m.width - 2).Height(m.height - lipgloss.Height(logBox)).Align(lipgloss.Center).Render(mainContent)
+
+	// Affiche la box principale puis la zone de log en bas
+	return outerBox + "\n" + logBox
+}
+
+// placeOrigin renvoie le décalage appliqué par lipgloss.Place pour centrer un
+// bloc de taille size dans un espace de taille total
+func placeOrigin(total, size int) int {
+	if size >= total {
+		return 0
+	}
+	return (total - size) / 2
+}
+
+// renderTooSmall affiche un écran d'avertissement quand le terminal est sous la taille minimale
+func (m Model) renderTooSmall(l layout) string {
+	text := tr("Terminal trop petit\n\n%d×%d (minimum %d×%d)\n\nAgrandissez la fenêtre\nou appuyez sur q pour quitter.", l.width, l.height, minWidth, minHeight)
+	box := activeTheme.fg(activeTheme.Passable).Bold(true).Align(lipgloss.Center).Render(text)
+	return lipgloss.Place(l.width, l.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// legendText renvoie le texte de la légende des indicateurs et des seuils du
+// profil p
+func legendText(p thresholdProfile) string {
+	legendText := lipgloss.NewStyle().Bold(true).Render(tr("E. coli")) + tr(" : ") + tr("Nombre de bactéries Escherichia coli pour 100ml d'eau (NPP = Nombre le Plus Probable)") + "\n"
+	legendText += lipgloss.NewStyle().Bold(true).Render(tr("Enté.")) + tr(" : ") + tr("Nombre d'entérocoques pour 100ml d'eau (NPP = Nombre le Plus Probable)") + "\n"
+	legendText += "\n" + tr("Seuils (%s) :", tr(p.Label)) + "\n"
+	level := func(l int) string {
+		return activeTheme.level(l).Render(levelSymbols[l] + " " + tr(levelNames[l]))
+	}
+	for _, colName := range []string{"E. coli", "Enté."} {
+		good, bad := p.limits(colName)
+		legendText += "- " + lipgloss.NewStyle().Bold(true).Render(tr(colName)) + fmt.Sprintf(" : ≤ %d (%s), ≤ %d (%s), > %d (%s)\n", good, level(0), bad, level(1), bad, level(2))
+	}
+	legendText += "\n" + tr("Classement des points (eaux côtières, percentiles log-normaux sur 4 ans) :") + "\n"
+	legendText += "- " + tr("P95 E. coli ≤ 250 et Enté. ≤ 100 : %s", classCell(classNames[classExcellent])) + "\n"
+	legendText += "- " + tr("P95 E. coli ≤ 500 et Enté. ≤ 200 : %s", classCell(classNames[classGood])) + "\n"
+	legendText += "- " + tr("P90 E. coli ≤ 500 et Enté. ≤ 185 : %s", classCell(classNames[classSufficient])) + "\n"
+	return legendText
+}
+
+// renderAboutPopup affiche l'écran "À propos", avec le QR code s'il tient à l'écran
+func (m Model) renderAboutPopup(l layout) string {
+	aboutText := "\n" + tr("Développé par Adrien S.") + "\nGitHub : " + repoURL + "\n"
+	// Génère le QR code ASCII avec go-qrcode
+	qrText := ""
+	qr, err := qrcode.New(repoURL, qrcode.Medium)
+	if err != nil {
+		qrText = tr("[QR code non disponible]")
+	} else {
+		// ToString() renders the QR code as ASCII. You can use ToString(false) for a smaller version, ToString(true) for a larger one.
+		qrText = qr.ToString(false)
+	}
+	// Bordure (2) + padding (2*1 vertical, 2*4 horizontal) + texte autour du QR code
+	qrWidth, qrHeight := lipgloss.Size(qrText)
+	if qrWidth+10 <= l.width && qrHeight+12 <= l.height {
+		aboutText += "\n" + tr("Scannez le QR code pour accéder au projet :") + "\n" + qrText
+	}
+	aboutText += "\n\n" + tr("Appuyez sur n'importe quelle touche pour revenir.")
+	return lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).BorderForeground(activeTheme.color(activeTheme.OK)).Padding(1, 4).Align(lipgloss.Center).MaxWidth(l.width).Render(aboutText)
+}
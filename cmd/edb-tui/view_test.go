@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/charmbracelet/x/exp/teatest"
+)
+
+// Tests de rendu : le modèle tourne dans un programme Bubbletea (teatest),
+// avec une horloge fixe et les CSV de testdata à la place du réseau. L'écran
+// final, sans les séquences de couleur, est comparé à sa référence
+// testdata/<Test>/<cas>.golden ; go test -update régénère les références.
+
+// testNow est l'heure fixe de l'horloge des tests
+var testNow = time.Date(2025, 10, 20, 9, 30, 0, 0, time.UTC)
+
+// Délai maximal d'attente d'un écran
+const testTimeout = 5 * time.Second
+
+func TestMain(m *testing.M) {
+	// Les dates des CSV sont lues dans le fuseau local
+	time.Local = time.UTC
+	if err := setLocale("fr"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// fixtureSource est une source de données qui lit le résumé et les
+// prélèvements details dans testdata, datés de testNow
+func fixtureSource(t *testing.T, details string) dataSource {
+	t.Helper()
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(id int, selection string, force bool, progress chan<- fetchProgressMsg) tea.Cmd {
+		return func() tea.Msg {
+			defer close(progress)
+			var tables [2][][]string
+			for i, name := range []string{"resume.csv", details} {
+				records, err := fetchCSVData(sourceURL(name, dir), func(int64, int64, bool) {})
+				if err != nil {
+					return err
+				}
+				tables[i] = records
+			}
+			return dataMsg{tables[0], tables[1], testNow, selection}
+		}
+	}
+}
+
+// screen décrit un écran à rendre : taille du terminal, CSV des
+// prélèvements, touches envoyées après l'arrivée des données et texte
+// attendu à l'écran avant de quitter
+type screen struct {
+	width, height int
+	details       string
+	keys          []string
+	want          string
+}
+
+// keyMsg renvoie le message d'une touche, nommée comme dans le keymap
+func keyMsg(k string) tea.KeyMsg {
+	switch k {
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+// waitText attend que la sortie du programme contienne text
+func waitText(t *testing.T, tm *teatest.TestModel, text string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(b []byte) bool {
+		return strings.Contains(ansi.Strip(string(b)), text)
+	}, teatest.WithDuration(testTimeout))
+}
+
+// render fait tourner le modèle jusqu'à l'écran décrit et renvoie sa vue
+// sans les séquences de couleur
+func render(t *testing.T, s screen) string {
+	t.Helper()
+	if s.details == "" {
+		s.details = "details.csv"
+	}
+	m := newModel(func() time.Time { return testNow }, fixtureSource(t, s.details))
+	m.clipboard = io.Discard
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(s.width, s.height))
+	if s.width >= minWidth && s.height >= minHeight {
+		// Les données arrivent avant les touches ; l'écran "terminal trop
+		// petit" ne les affiche pas
+		waitText(t, tm, "Données récupérées")
+	}
+	for _, k := range s.keys {
+		tm.Send(keyMsg(k))
+	}
+	if s.want != "" {
+		waitText(t, tm, s.want)
+	}
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(testTimeout))
+	return ansi.Strip(final.View())
+}
+
+func TestScreens(t *testing.T) {
+	tests := map[string]screen{
+		// Écran principal et onglets
+		"resume":  {width: 120, height: 36},
+		"details": {width: 120, height: 36, keys: []string{"2"}},
+		"stats":   {width: 120, height: 36, keys: []string{"s"}},
+		"history": {width: 120, height: 36, keys: []string{"4"}},
+		// Statistiques du filtre de l'onglet Détails (30 derniers jours), puis
+		// de tous les points sur toutes les dates
+		"stats-period": {width: 120, height: 36, keys: []string{"d", "d", "s"}, want: "période : "},
+		"stats-all":    {width: 120, height: 36, keys: []string{"d", "d", "s", "v", "v"}, want: "toutes les dates"},
+		"journal":      {width: 120, height: 36, keys: []string{"5"}},
+		"map":          {width: 120, height: 36, keys: []string{"6"}},
+
+		// Popups
+		"about":   {width: 120, height: 36, keys: []string{"a"}},
+		"legend":  {width: 120, height: 36, keys: []string{"l"}},
+		"help":    {width: 120, height: 36, keys: []string{"?"}},
+		"qr":      {width: 120, height: 48, keys: []string{"2", "Q"}, want: "Autre touche : fermer"},
+		"period":  {width: 120, height: 36, keys: []string{"D"}},
+		"compare": {width: 120, height: 36, keys: []string{"2", "m", "G", "m", "C"}, want: "Comparaison des plages marquées"},
+
+		// Largeurs étroites : colonnes masquées, puis écran trop petit
+		"details-narrow": {width: 100, height: 30, keys: []string{"2"}},
+		"details-tiny":   {width: 70, height: 24, keys: []string{"2"}},
+		"resume-tiny":    {width: 70, height: 24},
+		"too-small":      {width: 50, height: 16},
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			golden.RequireEqual(t, []byte(render(t, s)))
+		})
+	}
+}
+
+// Les variantes de noms de colonnes des indicateurs (e_coli_npp_100ml ou
+// ec_npp_100ml, enterocoques_npp_100ml ou ent_npp_100ml) donnent les mêmes
+// écrans
+func TestColumnAliases(t *testing.T) {
+	for _, keys := range [][]string{{"2"}, {"s"}, {"4"}} {
+		want := render(t, screen{width: 120, height: 36, keys: keys})
+		got := render(t, screen{width: 120, height: 36, keys: keys, details: "details-alias.csv"})
+		if got != want {
+			t.Errorf("onglet %s : écrans différents avec details-alias.csv\n%s\n---\n%s", keys[0], got, want)
+		}
+	}
+}